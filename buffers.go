@@ -0,0 +1,217 @@
+package csvi
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hymkor/csvi/internal/ansi"
+	"github.com/hymkor/csvi/internal/nonblock"
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// Buffer is a file edited with Config.EditBuffers.
+type Buffer struct {
+	// Name is shown on the tab line
+	Name string
+
+	// Source is the data of the file. With nil, the buffer starts with an empty row.
+	Source io.Reader
+
+	// Mode is the field separator and the encoding of the file.
+	// With nil, a copy of Config.Mode is used.
+	Mode *uncsv.Mode
+
+	// SavePath is the default file name to save the buffer.
+	// With "", Config.SavePath is used.
+	SavePath string
+
+	// SourcePath is the file which Source reads. See Config.SourcePath.
+	SourcePath string
+
+	// SwapPath is the swap file of the buffer. With "", no swap file is written.
+	// Config.SwapPath is not used because the buffers can not share it.
+	SwapPath string
+}
+
+// bufferList is the buffers edited at once and shared by them.
+type bufferList struct {
+	apps    []*Application
+	workers []*nonblock.NonBlock[*uncsv.Row]
+	current int
+}
+
+func (b *bufferList) multiple() bool {
+	return b != nil && len(b.apps) >= 2
+}
+
+func (b *bufferList) close() {
+	for _, w := range b.workers {
+		w.Close()
+	}
+	for _, app := range b.apps {
+		app.Close()
+	}
+}
+
+func (app *Application) title(i int) string {
+	title := fmt.Sprintf("%d:%s", i+1, app.name)
+	if app.IsDirty() {
+		title += " +"
+	}
+	return title
+}
+
+// drawTabLine draws the names of the buffers and highlights the current one.
+func (b *bufferList) drawTabLine(current *Application, out io.Writer) {
+	var buffer strings.Builder
+	for i, app := range b.apps {
+		if app == current {
+			buffer.WriteString("[" + app.title(i) + "]")
+		} else {
+			buffer.WriteString(" " + app.title(i) + " ")
+		}
+	}
+	io.WriteString(out, "\r"+ansi.YELLOW)
+	io.WriteString(out, truncate(buffer.String(), current.screenWidth-1, "..."))
+	io.WriteString(out, ansi.ERASE_LINE+"\r\n")
+}
+
+// allBuffers returns all the buffers including app.
+func (app *Application) allBuffers() []*Application {
+	if app.buffers == nil {
+		return []*Application{app}
+	}
+	return app.buffers.apps
+}
+
+// switchBuffer makes the buffer of index (counted from 0) active after the current command.
+func (app *Application) switchBuffer(index int) (string, error) {
+	if !app.buffers.multiple() {
+		return "", errors.New("Only one buffer")
+	}
+	n := len(app.buffers.apps)
+	if index < 0 || index >= n {
+		return "", fmt.Errorf("%d: no such buffer", index+1)
+	}
+	app.buffers.current = index
+	return app.buffers.apps[index].title(index), nil
+}
+
+func (app *Application) bufferIndex() int {
+	if app.buffers == nil {
+		return 0
+	}
+	return app.buffers.current
+}
+
+func cmdExBufferNext(e *CommandEventArgs) (*CommandResult, error) {
+	n := len(e.allBuffers())
+	message, err := e.switchBuffer((e.bufferIndex() + 1) % n)
+	if err != nil {
+		return nil, err
+	}
+	return messageResult(message)
+}
+
+func cmdExBufferPrevious(e *CommandEventArgs) (*CommandResult, error) {
+	n := len(e.allBuffers())
+	message, err := e.switchBuffer((e.bufferIndex() + n - 1) % n)
+	if err != nil {
+		return nil, err
+	}
+	return messageResult(message)
+}
+
+// cmdExBuffer switches to the buffer of the number counted from 1.
+func cmdExBuffer(e *CommandEventArgs) (*CommandResult, error) {
+	if len(e.Args) != 1 {
+		return nil, errors.New("Usage: b N")
+	}
+	n, err := strconv.Atoi(e.Args[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", e.Args[0], err)
+	}
+	message, err := e.switchBuffer(n - 1)
+	if err != nil {
+		return nil, err
+	}
+	return messageResult(message)
+}
+
+// cmdExBuffers lists the buffers. The current one is marked with `%`
+// and the changed ones with `+`.
+func cmdExBuffers(e *CommandEventArgs) (*CommandResult, error) {
+	var buffer strings.Builder
+	for i, app := range e.allBuffers() {
+		if i > 0 {
+			buffer.WriteString("  ")
+		}
+		if i == e.bufferIndex() {
+			buffer.WriteByte('%')
+		}
+		buffer.WriteString(app.title(i))
+	}
+	return messageResult(buffer.String())
+}
+
+// EditBuffers edits the files as separate buffers.
+// `:bn`, `:bp` and `:b N` switch them, and `:ls` lists them.
+// cfg is shared by them except Mode and SavePath of each Buffer.
+// DiffBase and Patch are applied only to the first buffer.
+func (cfg Config) EditBuffers(buffers []Buffer, ttyOut io.Writer) (*Result, error) {
+	if len(buffers) <= 0 {
+		return cfg.edit(nil, ttyOut)
+	}
+	configs := make([]*Config, 0, len(buffers))
+	fetches := make([]func() (*uncsv.Row, error), 0, len(buffers))
+	names := make([]string, 0, len(buffers))
+	for i, b := range buffers {
+		c := cfg
+		if b.Mode != nil {
+			c.Mode = b.Mode
+		} else if cfg.Mode != nil {
+			mode := *cfg.Mode
+			c.Mode = &mode
+		} else {
+			c.Mode = &uncsv.Mode{}
+		}
+		if b.SavePath != "" {
+			c.SavePath = b.SavePath
+		}
+		c.SourcePath = b.SourcePath
+		c.SwapPath = b.SwapPath
+		if cfg.CellWidth != nil {
+			c.CellWidth = cfg.CellWidth.clone()
+		}
+		if i > 0 {
+			c.DiffBase = nil
+			c.Patch = nil
+		}
+		var fetch func() (*uncsv.Row, error)
+		if b.Source != nil && c.Follow {
+			fetch, c.stopFetch = followFetch(c.watchSource(b.Source), c.Mode)
+		} else if ra, ok := b.Source.(io.ReaderAt); ok && c.Lazy {
+			fetch = c.lazyFetch(c.watchSource(b.Source), ra)
+		} else if isRegularFile(b.Source) {
+			fetch = c.parallelFetch(c.watchSource(b.Source))
+		} else if b.Source != nil {
+			br := bufio.NewReader(c.watchSource(b.Source))
+			mode := c.Mode
+			fetch = func() (*uncsv.Row, error) {
+				return uncsv.ReadLine(br, mode)
+			}
+		}
+		configs = append(configs, &c)
+		fetches = append(fetches, fetch)
+		names = append(names, b.Name)
+	}
+	return cfg.editBuffers(configs, fetches, names, ttyOut)
+}
+
+func (app *Application) result() *Result {
+	return &Result{Application: app, Buffers: app.allBuffers()}
+}
@@ -0,0 +1,35 @@
+package csvi
+
+import (
+	"testing"
+)
+
+func TestBufferList(t *testing.T) {
+	list := &bufferList{}
+	for _, name := range []string{"a.csv", "b.tsv", "c.csv"} {
+		app := &Application{Config: &Config{}, name: name, buffers: list}
+		list.apps = append(list.apps, app)
+	}
+	list.apps[2].setHardDirty()
+	e := &CommandEventArgs{Application: list.apps[0]}
+
+	rc, err := cmdExBufferPrevious(e)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if list.current != 2 || rc.Message != "3:c.csv +" {
+		t.Fatalf("bp: current=%d message=%q", list.current, rc.Message)
+	}
+	e.Args = []string{"2"}
+	if _, err := cmdExBuffer(e); err != nil || list.current != 1 {
+		t.Fatalf("b 2: current=%d err=%v", list.current, err)
+	}
+	e.Args = []string{"4"}
+	if _, err := cmdExBuffer(e); err == nil {
+		t.Fatal("b 4 must fail")
+	}
+	rc, _ = cmdExBuffers(e)
+	if rc.Message != "1:a.csv  %2:b.tsv  3:c.csv +" {
+		t.Fatalf("ls: %q", rc.Message)
+	}
+}
@@ -0,0 +1,48 @@
+package csvi_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func testBuffers(t *testing.T, script string, files ...string) {
+	t.Helper()
+	args := append([]string{"-auto", script}, files...)
+	instance, err := newTestOptions(args...)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	enable := disableStdout(t)
+	err = instance.Run()
+	enable()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestBuffersOwnMode(t *testing.T) {
+	csvPath := makeSource(t, "a.csv", "a,b\n")
+	tsvPath := makeSource(t, "b.tsv", "\xEF\xBB\xBFc\td\n")
+	dir := t.TempDir()
+	output1 := filepath.Join(dir, "out1.csv")
+	output2 := filepath.Join(dir, "out2.tsv")
+
+	// :bp wraps around to the last buffer and :b N selects the N-th one
+	testBuffers(t,
+		fmt.Sprintf(":|bp|l|r|X|w|%s|:|b 1|l|r|Y|w|%s|q", output2, output1),
+		csvPath, tsvPath)
+	checkResult(t, output1, "a,Y\n")
+	checkResult(t, output2, "\xEF\xBB\xBFc\tX\n")
+}
+
+func TestBuffersQuit(t *testing.T) {
+	path1 := makeSource(t, "a.csv", "a\n")
+	path2 := makeSource(t, "b.csv", "b\n")
+	output := filepath.Join(t.TempDir(), "out.csv")
+
+	// q asks about every buffer changed
+	testBuffers(t, fmt.Sprintf("r|X|:|bn|r|Y|q|y|%s|n", output), path1, path2)
+	checkResult(t, output, "X\n")
+	checkResult(t, path2, "b\n")
+}
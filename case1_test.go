@@ -1,6 +1,7 @@
 package csvi_test
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -227,3 +228,12 @@ func TestSearchTailDownAppend(t *testing.T) {
 わ,を,ん`
 	testCase(t, aiueo, op, exp)
 }
+
+func TestFreezeColumn(t *testing.T) {
+	src := strings.Repeat("0123456789,", 20) + "x\n"
+	op := strings.Repeat("l|", 15) + "r|X|" + "0|r|Y"
+	exp := "Y," + strings.Repeat("0123456789,", 14) + "X," + strings.Repeat("0123456789,", 4) + "x\n"
+
+	testCase(t, src, op, exp, "-freezecol", "2")
+	testCase(t, src, "Z|"+op, exp)
+}
@@ -13,9 +13,15 @@ func TestCase3(t *testing.T) {
 	path1 := makeSource(t, "t1.csv", "first\n")
 	path2 := makeSource(t, "t2.csv", "second\n")
 	path3 := makeSource(t, "t3.csv", "third\n")
-	outputPath := filepath.Join(t.TempDir(), "t4.csv")
+	dir := t.TempDir()
+	output1 := filepath.Join(dir, "t4.csv")
+	output2 := filepath.Join(dir, "t5.csv")
+	output3 := filepath.Join(dir, "t6.csv")
 
-	instance, err := newTestOptions("-auto", fmt.Sprintf("w|%s|q|y", outputPath), path1, path2, path3)
+	// each file is opened as its own buffer
+	instance, err := newTestOptions("-auto",
+		fmt.Sprintf("w|%s|:|bn|w|%s|:|bn|w|%s|q|y", output1, output2, output3),
+		path1, path2, path3)
 
 	if err != nil {
 		t.Fatal(err.Error())
@@ -26,7 +32,9 @@ func TestCase3(t *testing.T) {
 	if err != nil {
 		t.Fatal(err.Error())
 	}
-	checkResult(t, outputPath, "first\nsecond\nthird\n")
+	checkResult(t, output1, "first\n")
+	checkResult(t, output2, "second\n")
+	checkResult(t, output3, "third\n")
 }
 
 func TestDataStreamIsNil(t *testing.T) {
@@ -1,25 +1,59 @@
 package csvi
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/hymkor/csvi/uncsv"
 )
 
 type CellWidth struct {
 	Default int
 	Option  map[int]int
+	// Auto makes the widths of the columns without Option fit their contents
+	// between AutoMin and AutoMax.
+	Auto    bool
+	AutoMin int
+	AutoMax int
+	fitted  map[int]int
 }
 
 func NewCellWidth() *CellWidth {
 	cw := &CellWidth{
 		Default: 14,
 		Option:  map[int]int{},
+		AutoMin: 4,
+		AutoMax: 40,
 	}
 	return cw
 }
 
+// clone returns a copy for another buffer.
+func (cw *CellWidth) clone() *CellWidth {
+	dup := *cw
+	dup.Option = make(map[int]int, len(cw.Option))
+	for k, v := range cw.Option {
+		dup.Option[k] = v
+	}
+	dup.fitted = nil
+	return &dup
+}
+
+// base returns the width of the column when Option is not set.
+func (cw *CellWidth) base(n int) int {
+	if cw.Auto {
+		if val, ok := cw.fitted[n]; ok {
+			return val
+		}
+	}
+	return cw.Default
+}
+
 func (cw *CellWidth) Set(at, value int) bool {
-	if value == cw.Default {
+	if value == cw.base(at) {
 		delete(cw.Option, at)
 	} else {
 		cw.Option[at] = value
@@ -31,7 +65,58 @@ func (cw *CellWidth) Get(n int) int {
 	if val, ok := cw.Option[n]; ok {
 		return val
 	}
-	return cw.Default
+	return cw.base(n)
+}
+
+// Fit widens the fitted widths of the columns for cells.
+// It returns true when some width is changed.
+func (cw *CellWidth) Fit(cells []uncsv.Cell) bool {
+	if cw.fitted == nil {
+		cw.fitted = map[int]int{}
+	}
+	changed := false
+	for i, c := range cells {
+		// one more column for the space between cells
+		w := runewidth.StringWidth(replaceTable.Replace(c.Text())) + 1
+		if w < cw.AutoMin {
+			w = cw.AutoMin
+		}
+		if cw.AutoMax > 0 && w > cw.AutoMax {
+			w = cw.AutoMax
+		}
+		if old, ok := cw.fitted[i]; !ok || w > old {
+			cw.fitted[i] = w
+			changed = true
+		}
+	}
+	return changed
+}
+
+// ResetFit forgets the fitted widths.
+func (cw *CellWidth) ResetFit() {
+	cw.fitted = nil
+}
+
+func (cw *CellWidth) parseAuto(s string) error {
+	cw.Auto = true
+	fields := strings.Split(s, ":")
+	if len(fields) == 1 {
+		return nil
+	}
+	if len(fields) != 3 {
+		return fmt.Errorf("%s: expected auto:MIN:MAX", s)
+	}
+	minWidth, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return err
+	}
+	maxWidth, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		return err
+	}
+	cw.AutoMin = int(minWidth)
+	cw.AutoMax = int(maxWidth)
+	return nil
 }
 
 func (cw *CellWidth) Parse(s string) error {
@@ -39,6 +124,12 @@ func (cw *CellWidth) Parse(s string) error {
 	cont := true
 	for cont {
 		p, s, cont = strings.Cut(s, ",")
+		if p == "auto" || strings.HasPrefix(p, "auto:") {
+			if err := cw.parseAuto(p); err != nil {
+				return err
+			}
+			continue
+		}
 		left, right, ok := strings.Cut(p, ":")
 		if ok {
 			leftN, err := strconv.ParseUint(left, 10, 64)
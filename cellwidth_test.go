@@ -1,7 +1,10 @@
 package csvi
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/hymkor/csvi/uncsv"
 )
 
 func TestCwParse(t *testing.T) {
@@ -29,3 +32,40 @@ func TestCwParse(t *testing.T) {
 		t.Fatalf("Get(7) = %d", w)
 	}
 }
+
+func TestCwAuto(t *testing.T) {
+	cw := NewCellWidth()
+	if err := cw.Parse("auto:5:20,2:30"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !cw.Auto || cw.AutoMin != 5 || cw.AutoMax != 20 {
+		t.Fatalf("Auto=%v AutoMin=%d AutoMax=%d", cw.Auto, cw.AutoMin, cw.AutoMax)
+	}
+	mode := &uncsv.Mode{Comma: ','}
+	row := uncsv.NewRowFromStringSlice(mode, []string{"a", "あいうえおかきくけこ", "x", strings.Repeat("z", 50)})
+	if !cw.Fit(row.Cell) {
+		t.Fatal("Fit must return true for the first row")
+	}
+	for i, expect := range []int{5, 20, 30, 20} {
+		if w := cw.Get(i); w != expect {
+			t.Fatalf("Get(%d) = %d, expect %d", i, w, expect)
+		}
+	}
+	row = uncsv.NewRowFromStringSlice(mode, []string{"abcdefg"})
+	if !cw.Fit(row.Cell) || cw.Get(0) != 8 {
+		t.Fatalf("Get(0) = %d", cw.Get(0))
+	}
+	if cw.Fit(row.Cell) {
+		t.Fatal("Fit must return false when nothing is widened")
+	}
+	if w := cw.Get(9); w != cw.Default {
+		t.Fatalf("Get(9) = %d", w)
+	}
+	cw.Set(0, 9)
+	if w := cw.Get(0); w != 9 {
+		t.Fatalf("Get(0) = %d after Set", w)
+	}
+	if err := NewCellWidth().Parse("auto:5"); err == nil {
+		t.Fatal("auto:5 must be an error")
+	}
+}
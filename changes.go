@@ -0,0 +1,413 @@
+package csvi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nyaosorg/go-readline-ny/keys"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// baseRow is a row of the file as loaded or saved last.
+// The changes are the differences from them.
+type baseRow struct {
+	lnum int // counted from 0 in the file
+	// image is nil for the row read with Config.Lazy and not changed then
+	// because it can be read again from the file.
+	image rowImage
+}
+
+// cells returns the cells of the base of row.
+func (b *baseRow) cells(row *uncsv.Row) []uncsv.Cell {
+	if b.image.cell != nil {
+		return b.image.cell
+	}
+	source, err := row.ReadSource()
+	if err != nil {
+		return nil
+	}
+	return source.Cell
+}
+
+// load appends the row read from the file.
+// The row following the run at the end in the file of Config.Lazy
+// is appended to the run without keeping it (see rowList).
+func (app *Application) load(row *uncsv.Row) {
+	if app.base == nil {
+		app.base = map[*uncsv.Row]*baseRow{}
+	}
+	if app.csvLines.extend(row) {
+		app.fitCellWidth(row)
+		app.baseLen++
+		return
+	}
+	app.push(row)
+	b := &baseRow{lnum: app.baseLen}
+	if !row.Unload() {
+		b.image = takeRowImage(row)
+	} else {
+		app.csvLines.growing = app.csvLines.Back()
+	}
+	app.base[row] = b
+	app.baseLen++
+}
+
+// splitBase gives the base to the row taken out of the run of head.
+func (app *Application) splitBase(head, row *uncsv.Row, offset int) {
+	if b, ok := app.base[head]; ok {
+		app.base[row] = &baseRow{lnum: b.lnum + offset}
+	}
+}
+
+// rebase makes the current rows the base of the changes
+// after they are written to the file.
+func (app *Application) rebase() {
+	app.base = map[*uncsv.Row]*baseRow{}
+	app.baseLen = 0
+	app.scanRows(func(e *rowElement) bool {
+		row := e.Value
+		if app.isGhost(row) {
+			return true
+		}
+		b := &baseRow{lnum: app.baseLen}
+		if !row.InSource() {
+			b.image = takeRowImage(row)
+		}
+		app.base[row] = b
+		app.baseLen += e.Weight()
+		return true
+	})
+}
+
+func cellTexts(cells []uncsv.Cell) []string {
+	texts := make([]string, 0, len(cells))
+	for _, c := range cells {
+		texts = append(texts, c.Text())
+	}
+	return texts
+}
+
+func equalTexts(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// change is a change of a row with the row in the buffer.
+// element is the element of the row, which is removed for "delete".
+type change struct {
+	Change
+	row     *uncsv.Row
+	element *rowElement
+}
+
+// collectChanges returns the changes from the base in the order of the rows.
+// Changes only of quotation are ignored because they do not change texts.
+func (app *Application) collectChanges() []*change {
+	inList := map[*uncsv.Row]bool{}
+	app.scanRows(func(e *rowElement) bool {
+		inList[e.Value] = true
+		return true
+	})
+	removed := map[*uncsv.Row]*rowElement{}
+	for _, e := range app.removedRows {
+		removed[e.Value] = e
+	}
+	var deleted []*change
+	for row, b := range app.base {
+		if !inList[row] {
+			e := removed[row]
+			if e == nil {
+				e = &rowElement{Value: row}
+			}
+			deleted = append(deleted, &change{
+				Change:  Change{Op: "delete", Row: b.lnum + 1, Old: cellTexts(b.cells(row))},
+				row:     row,
+				element: e,
+			})
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i].Row < deleted[j].Row })
+
+	var changes []*change
+	last := 0
+	app.scanRows(func(e *rowElement) bool {
+		row := e.Value
+		if app.isGhost(row) {
+			return true
+		}
+		b, ok := app.base[row]
+		if !ok {
+			changes = append(changes, &change{
+				Change:  Change{Op: "insert", Row: last, New: cellTexts(row.Cell)},
+				row:     row,
+				element: e,
+			})
+			return true
+		}
+		for len(deleted) > 0 && deleted[0].Row <= b.lnum {
+			changes = append(changes, deleted[0])
+			deleted = deleted[1:]
+		}
+		last = b.lnum + e.Weight()
+		if b.image.cell == nil && row.InSource() {
+			return true
+		}
+		old := cellTexts(b.cells(row))
+		new := cellTexts(row.Cell)
+		if !equalTexts(old, new) {
+			changes = append(changes, &change{
+				Change:  Change{Op: "update", Row: b.lnum + 1, Old: old, New: new},
+				row:     row,
+				element: e,
+			})
+		}
+		return true
+	})
+	return append(changes, deleted...)
+}
+
+// reviewItem is a line of the review of the changes.
+// An update of a row which keeps the number of cells is shown by the cell.
+type reviewItem struct {
+	*change
+	col int // -1 for the whole row
+}
+
+func reviewItems(changes []*change) []reviewItem {
+	var items []reviewItem
+	for _, c := range changes {
+		if c.Op != "update" || len(c.Old) != len(c.New) {
+			items = append(items, reviewItem{change: c, col: -1})
+			continue
+		}
+		for i := range c.Old {
+			if c.Old[i] != c.New[i] {
+				items = append(items, reviewItem{change: c, col: i})
+			}
+		}
+	}
+	return items
+}
+
+func joinCells(texts []string) string {
+	return replaceTable.Replace(strings.Join(texts, ","))
+}
+
+func (item reviewItem) String() string {
+	switch item.Op {
+	case "insert":
+		if item.Row <= 0 {
+			return "insert at the top: " + joinCells(item.New)
+		}
+		return fmt.Sprintf("insert after row %d: %s", item.Row, joinCells(item.New))
+	case "delete":
+		return fmt.Sprintf("delete row %d: %s", item.Row, joinCells(item.Old))
+	}
+	if item.col < 0 {
+		return fmt.Sprintf("row %d: %s -> %s", item.Row, joinCells(item.Old), joinCells(item.New))
+	}
+	return fmt.Sprintf("row %d, col %d: %q -> %q",
+		item.Row, item.col+1,
+		replaceTable.Replace(item.Old[item.col]),
+		replaceTable.Replace(item.New[item.col]))
+}
+
+// resyncCursor updates the line numbers of the cursor and the top of the screen
+// after rows out of them are inserted or removed.
+func (app *Application) resyncCursor() {
+	lnum := app.cursorRow.Index()
+	if p := app.rowPtrOf(app.startRow.element); p != nil {
+		app.startRow = p
+	} else {
+		app.startRow = app.Front()
+	}
+	if p := app.rowPtrOf(app.cursorRow.element); p != nil {
+		app.cursorRow = p
+	} else {
+		if lnum >= app.Len() {
+			lnum = app.Len() - 1
+		}
+		app.cursorRow = app.seek(lnum)
+	}
+	if app.startRow.Index() > app.cursorRow.Index() {
+		app.startRow = app.cursorRow.Clone()
+	}
+	app.invalidateFilterCount()
+	app.invalidateGhosts()
+	app.clearCache()
+}
+
+// revert puts the item back to the base.
+func (app *Application) revert(item reviewItem) error {
+	if app.ReadOnly {
+		return errors.New(msgReadOnly)
+	}
+	switch item.Op {
+	case "insert":
+		p := app.rowPtrOf(item.element)
+		if p == nil {
+			return errMarkDeleted
+		}
+		if app.Len() <= 1 {
+			return errors.New("The last row can not be removed")
+		}
+		head := app.Front()
+		app.removeCurrentRow(&head, &p)
+	case "delete":
+		b := app.base[item.row]
+		var anchorElement *rowElement
+		app.scanRows(func(e *rowElement) bool {
+			if a, ok := app.base[e.Value]; ok && a.lnum < b.lnum {
+				if anchorElement == nil || app.base[anchorElement.Value].lnum < a.lnum {
+					anchorElement = e
+				}
+			}
+			return true
+		})
+		lnum := 0
+		if anchorElement != nil {
+			// the deleted row was after the last row of the run of the anchor
+			anchor := app.seek(anchorElement.Index() + anchorElement.Weight() - 1)
+			lnum = anchor.Index() + 1
+			if anchor.Next() == nil && anchor.Term == "" {
+				before := takeRowImage(anchor.Row)
+				anchor.Term = app.Mode.DefaultTerm
+				app.recordRowChange(anchor.Row, before)
+			}
+		}
+		app.insertElementAt(lnum, item.element)
+		app.recordRestoreRow(lnum, item.element)
+	default:
+		b := app.base[item.row]
+		before := takeRowImage(item.row)
+		cells := b.cells(item.row)
+		if item.col < 0 {
+			item.row.Cell = append([]uncsv.Cell{}, cells...)
+		} else {
+			item.row.Cell[item.col] = cells[item.col]
+		}
+		app.recordRowChange(item.row, before)
+	}
+	app.setHardDirty()
+	app.resyncCursor()
+	return nil
+}
+
+// jumpToChange moves the cursor to the row of the item
+// or to the row where the deleted row was.
+func (app *Application) jumpToChange(item reviewItem) error {
+	target := item.element
+	if item.Op == "delete" {
+		var found *rowElement
+		app.scanRows(func(e *rowElement) bool {
+			if b, ok := app.base[e.Value]; ok && b.lnum >= item.Row-1 {
+				found = e
+				return false
+			}
+			return true
+		})
+		if found == nil {
+			found = app.Back().element
+		}
+		target = found
+	}
+	col := item.col
+	if col < 0 {
+		col = app.cursorCol
+	}
+	app.rememberJump()
+	return app.moveTo(position{element: target, col: col})
+}
+
+// cmdReviewChanges shows the changes not saved yet.
+// j/k selects one, Enter jumps to it, U reverts it,
+// w writes all of them as a patch and q closes the list.
+func (app *Application) cmdReviewChanges() string {
+	defer app.clearCache()
+	index := 0
+	top := 0
+	message := ""
+	for first := true; ; first = false {
+		items := reviewItems(app.collectChanges())
+		if len(items) <= 0 && first {
+			return "No changes"
+		}
+		if index >= len(items) && index > 0 {
+			index = len(items) - 1
+		}
+		height := app.lfCount - 1
+		if height < 1 {
+			height = 1
+		}
+		if index < top {
+			top = index
+		} else if index >= top+height {
+			top = index - height + 1
+		}
+		lines := []string{fmt.Sprintf("%d changes (j/k:select, Enter:jump, U:revert, w:write patch, q:close)", len(items))}
+		for i := top; i < len(items) && i < top+height; i++ {
+			mark := "  "
+			if i == index {
+				mark = "> "
+			}
+			lines = append(lines, mark+items[i].String())
+		}
+		if len(items) <= 0 {
+			lines = append(lines, "  (all changes are reverted)")
+		}
+		app.drawPanel(lines)
+		if message == "" {
+			message = "Select a change"
+		}
+		ch, err := app.MessageAndGetKey(message)
+		if err != nil {
+			return err.Error()
+		}
+		message = ""
+		switch ch {
+		case "j", keys.Down, keys.CtrlN:
+			if index+1 < len(items) {
+				index++
+			}
+		case "k", keys.Up, keys.CtrlP:
+			if index > 0 {
+				index--
+			}
+		case "q", keys.Escape:
+			return ""
+		}
+		if len(items) <= 0 {
+			continue
+		}
+		switch ch {
+		case keys.Enter:
+			if err := app.jumpToChange(items[index]); err != nil {
+				return err.Error()
+			}
+			return ""
+		case "U":
+			if err := app.revert(items[index]); err != nil {
+				message = err.Error()
+			} else {
+				message = "Reverted"
+			}
+		case "w":
+			fname, err := app.GetFilename(app, "patch to>", "")
+			if err != nil {
+				message = err.Error()
+			} else if message, err = app.writePatchFile(fname); err != nil {
+				message = err.Error()
+			}
+		}
+	}
+}
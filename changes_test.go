@@ -0,0 +1,95 @@
+package csvi_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReviewRevert(t *testing.T) {
+	source := "a,b\nc,d\ne,f\n"
+	// U in the review reverts the selected change
+	testCase(t, source, "j|l|r|X|C|U|q", source)
+	testCase(t, source, "o|N|C|U|q", source)
+	testCase(t, source, "j|d|r|C|U|q", source)
+	testCase(t, source, "G|d|r|C|U|q", source)
+	// only the selected change is reverted
+	testCase(t, source, "r|X|j|r|Y|C|j|U|q", "X,b\nc,d\ne,f\n")
+	// the revert can be undone
+	testCase(t, source, "r|X|C|U|q|u", "X,b\nc,d\ne,f\n")
+	// Enter jumps to the change
+	testCase(t, source, "G|l|r|X|<|C|\r|r|Y", "a,b\nc,d\ne,Y\n")
+}
+
+func TestPatch(t *testing.T) {
+	source := "a,b\nc,d\ne,f\n"
+	patch := filepath.Join(t.TempDir(), "test.jsonl")
+	testRun(t, strings.NewReader(source), "-auto",
+		fmt.Sprintf("j|l|r|X|j|d|r|<|o|N|:|patch %s|q|y", patch))
+	checkResult(t, patch,
+		`{"op":"insert","row":1,"new":["N"]}`+"\n"+
+			`{"op":"update","row":2,"old":["c","d"],"new":["c","X"]}`+"\n"+
+			`{"op":"delete","row":3,"old":["e","f"]}`+"\n")
+
+	testCase(t, source, "", "a,b\nN\nc,X\n", "-apply", patch)
+	// the patch applied can be undone
+	testCase(t, source, "u", source, "-apply", patch)
+
+	opt, err := newTestOptions("-apply", patch, "-auto", "q|y")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = opt.RunInOut(strings.NewReader("a,b\nc,D\ne,f\n"), os.Stderr)
+	if err == nil || !strings.Contains(err.Error(), "row 2") {
+		t.Fatalf("the patch must not be applied to other data: %v", err)
+	}
+}
+
+func TestPatchConflict(t *testing.T) {
+	dir := t.TempDir()
+	for _, c := range []struct {
+		source string
+		patch  string
+		expect string
+	}{
+		{
+			source: "a\nb\nc\n",
+			patch: `{"op":"delete","row":2,"old":["b"]}` + "\n" +
+				`{"op":"delete","row":2,"old":["b"]}` + "\n",
+			expect: "row 2: delete after delete",
+		},
+		{
+			source: "a\nb\nc\n",
+			patch: `{"op":"update","row":2,"old":["b"],"new":["B"]}` + "\n" +
+				`{"op":"delete","row":2,"old":["b"]}` + "\n",
+			expect: "row 2: delete after update",
+		},
+		{
+			source: "a\n",
+			patch:  `{"op":"delete","row":1,"old":["a"]}` + "\n",
+			expect: "deletes all the rows",
+		},
+	} {
+		patch := filepath.Join(dir, "test.jsonl")
+		if err := os.WriteFile(patch, []byte(c.patch), 0666); err != nil {
+			t.Fatal(err.Error())
+		}
+		opt, err := newTestOptions("-apply", patch, "-auto", "q|y")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		err = opt.RunInOut(strings.NewReader(c.source), io.Discard)
+		if err == nil || !strings.Contains(err.Error(), c.expect) {
+			t.Fatalf("%q: expect %q, but %v", c.patch, c.expect, err)
+		}
+	}
+	// a row can be deleted when a row is inserted
+	source := "a\n"
+	patch := makeSource(t, "test.jsonl",
+		`{"op":"insert","row":1,"new":["N"]}`+"\n"+
+			`{"op":"delete","row":1,"old":["a"]}`+"\n")
+	testCase(t, source, "", "N\n", "-apply", patch)
+}
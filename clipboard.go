@@ -0,0 +1,127 @@
+package csvi
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// Clipboard is the clipboard shared with other applications.
+// It has the same methods as readline.Clipboard.
+type Clipboard interface {
+	Read() (string, error)
+	Write(string) error
+}
+
+// clipboardMode returns the format of the text on the clipboard.
+// It is TSV unless ClipboardCSV is set.
+func (app *Application) clipboardMode() *uncsv.Mode {
+	mode := &uncsv.Mode{Comma: '\t'}
+	if app.ClipboardCSV {
+		mode.Comma = app.Mode.Comma
+	}
+	return mode
+}
+
+// writeClipboard sends text to the terminal with OSC 52 unless Config.NoOSC52 is set,
+// and to Config.Clipboard when it is set.
+func (app *Application) writeClipboard(text string) error {
+	app.clipText = text
+	if !app.NoOSC52 {
+		fmt.Fprintf(app.out, "\x1B]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	}
+	if app.Clipboard != nil {
+		return app.Clipboard.Write(text)
+	}
+	return nil
+}
+
+// readClipboard returns the text of Config.Clipboard.
+// Without it, the text copied last by csvi is returned
+// because the terminal does not always let OSC 52 be read.
+func (app *Application) readClipboard() (string, error) {
+	if app.Clipboard != nil {
+		return app.Clipboard.Read()
+	}
+	return app.clipText, nil
+}
+
+// copyRange sends the cells between the rows top..bottom and the columns left..right
+// to the clipboard. right < 0 means the end of each row.
+func (app *Application) copyRange(top, bottom, left, right int) error {
+	mode := app.clipboardMode()
+	var buffer strings.Builder
+	for p := app.seek(top); p != nil && p.Index() <= bottom; p = p.Next() {
+		if !app.isVisible(p) {
+			continue
+		}
+		end := right + 1
+		if right < 0 || end > len(p.Cell) {
+			end = len(p.Cell)
+		}
+		texts := []string{}
+		for c := left; c < end; c++ {
+			texts = append(texts, p.Cell[c].Text())
+		}
+		if len(texts) <= 0 {
+			texts = append(texts, "")
+		}
+		row := uncsv.NewRowFromStringSlice(mode, texts)
+		row.Term = ""
+		if buffer.Len() > 0 {
+			buffer.WriteString(uncsv.OsNewline)
+		}
+		buffer.Write(row.Rebuild(mode))
+	}
+	return app.writeClipboard(buffer.String())
+}
+
+func (app *Application) copySelection() error {
+	top, bottom, left, right := app.selectionRange()
+	if app.selection.mode == selectRows {
+		return app.copyRange(top, bottom, 0, -1)
+	}
+	return app.copyRange(top, bottom, left, right)
+}
+
+// pasteFromClipboard parses the text of the clipboard as CSV/TSV
+// and pastes it as a block of cells.
+func (app *Application) pasteFromClipboard(pt pasteType) error {
+	text, err := app.readClipboard()
+	if err != nil {
+		return err
+	}
+	rows, err := uncsv.ReadAll(strings.NewReader(text), app.clipboardMode())
+	if err != nil {
+		return err
+	}
+	if len(rows) <= 0 {
+		return errors.New("Clipboard is empty")
+	}
+	block := make([][]uncsv.Cell, 0, len(rows))
+	for _, row := range rows {
+		texts := make([]string, 0, len(row.Cell))
+		for _, c := range row.Cell {
+			texts = append(texts, c.Text())
+		}
+		block = append(block, uncsv.NewRowFromStringSlice(app.Mode, texts).Cell)
+	}
+	return app.makeBlockPaster(block)(&app.startRow, &app.cursorRow, &app.cursorCol, pt)
+}
+
+func (app *Application) copyCellToClipboard() error {
+	lnum := app.cursorRow.Index()
+	return app.copyRange(lnum, lnum, app.cursorCol, app.cursorCol)
+}
+
+func (app *Application) copyRowToClipboard() error {
+	lnum := app.cursorRow.Index()
+	return app.copyRange(lnum, lnum, 0, -1)
+}
+
+func (app *Application) copyColumnToClipboard() error {
+	return app.copyRange(0, app.Len()-1, app.cursorCol, app.cursorCol)
+}
@@ -0,0 +1,46 @@
+package csvi_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hymkor/csvi"
+	"github.com/hymkor/csvi/uncsv"
+)
+
+func TestClipboardPaste(t *testing.T) { // `g`+`p`
+	src := "a,b,c\nd,e,f"
+
+	testCase(t, src, "y|l|j|g|p", "a,b,c\nd,a,e,f")
+	testCase(t, src, "Y|j|g|P", "a,b,c\na,b,c,d,e,f")
+	testCase(t, src, "d|l|j|g|\x1Bp", "b,c\na,e,f")
+	testCase(t, src, "v|j|l|y|l|g|p", "a,b,a,b,c\nd,e,d,e,f")
+	testCase(t, src, "y|c|l|g|\x1Bp", "a,a,c\nd,d,f")
+
+	testCase(t, src, "y|l|j|g|p", src, "-fixcol")   // can not update
+	testCase(t, src, "y|l|j|g|p", src, "-readonly") // can not update
+}
+
+func TestClipboardQuote(t *testing.T) {
+	src := "\"a,b\",\"c\"\"d\",e\nf"
+
+	testCase(t, src, "v|l|y|j|g|p", "\"a,b\",\"c\"\"d\",e\nf,\"a,b\",\"c\"\"d\"")
+	testCase(t, src, "v|l|y|j|g|p", "\"a,b\",\"c\"\"d\",e\nf,\"a,b\",\"c\"\"d\"", "-clipcsv")
+}
+
+func TestClipboardNoOSC52(t *testing.T) {
+	for _, noOSC52 := range []bool{false, true} {
+		var out strings.Builder
+		cfg := &csvi.Config{
+			Mode:    &uncsv.Mode{Comma: ','},
+			Pilot:   &scriptPilot{script: strings.Split("y|c|q|y", "|")},
+			NoOSC52: noOSC52,
+		}
+		if _, err := cfg.Edit(strings.NewReader("a,b\n"), &out); err != nil {
+			t.Fatal(err.Error())
+		}
+		if sent := strings.Contains(out.String(), "\x1B]52;"); sent == noOSC52 {
+			t.Fatalf("NoOSC52=%v: OSC 52 sent=%v", noOSC52, sent)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package csvi_test
+
+import (
+	"testing"
+)
+
+func TestConfigBind(t *testing.T) {
+	config := makeSource(t, "config", `# delete the cell with x like vi
+bind x delete-cell
+bind X clear-cell
+bind C-K :goto 2 2
+unbind d
+`)
+	src := "a,b,c\nd,e,f"
+	testCase(t, src, "x", "b,c\nd,e,f", "-config", config)
+	testCase(t, src, "X", ",b,c\nd,e,f", "-config", config)
+	testCase(t, src, "d|l|r|X", "a,X,c\nd,e,f", "-config", config)
+	testCase(t, src, "\x0B|r|X", "a,b,c\nd,X,f", "-config", config)
+}
+
+func TestConfigSet(t *testing.T) {
+	config := makeSource(t, "config", "set h=0\nset readonly\n")
+	src := "b\na"
+	testCase(t, src, "S|s|r|X", src, "-config", config)
+	testCase(t, src, "S|s", "a\nb", "-config", config, "-readonly=false")
+}
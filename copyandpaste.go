@@ -25,7 +25,9 @@ func (app *Application) yankCurrentCell(src *RowPtr, col int) pasteFunc {
 			if m := app.checkWriteProtect(*dst); m != "" {
 				return errors.New(m)
 			}
+			before := takeRowImage((*dst).Row)
 			(*dst).Cell[*dcol].SetSource(dup.Source(), app.Config.Mode)
+			app.recordRowChange((*dst).Row, before)
 		} else {
 			if m := app.checkWriteProtectAndColumn(*dst); m != "" {
 				return errors.New(m)
@@ -33,7 +35,9 @@ func (app *Application) yankCurrentCell(src *RowPtr, col int) pasteFunc {
 			if pt == pasteAfter {
 				(*dcol)++
 			}
+			before := takeRowImage((*dst).Row)
 			(*dst).InsertCell(*dcol, dup, app.Config.Mode)
+			app.recordRowChange((*dst).Row, before)
 		}
 		return nil
 	}
@@ -42,45 +46,80 @@ func (app *Application) yankCurrentCell(src *RowPtr, col int) pasteFunc {
 
 func (app *Application) removeCurrentCell(src *RowPtr, col int) pasteFunc {
 	paste := app.yankCurrentCell(src, col)
+	before := takeRowImage(src.Row)
 	if len(src.Cell) <= 1 {
 		src.Replace(0, "", app.Config.Mode)
 	} else {
 		src.Delete(col)
 	}
+	app.recordRowChange(src.Row, before)
 	return paste
 }
 
-func (app *Application) makeRowPaster(dup *uncsv.Row) pasteFunc {
+// makeRowPaster returns the pasteFunc for rows. dups are copied on each paste.
+func (app *Application) makeRowPaster(dups ...*uncsv.Row) pasteFunc {
 	paste := func(head, dst **RowPtr, _ *int, pt pasteType) error {
 		if m := app.checkWriteProtect(*dst); m != "" {
 			return errors.New(m)
 		}
 		if pt == pasteOver {
-			for i := 0; i < len(dup.Cell); i++ {
-				if i >= len((*dst).Cell) {
-					(*dst).Cell = append((*dst).Cell, dup.Cell[i])
-				} else {
-					(*dst).Cell[i].SetSource(dup.Cell[i].Source(), app.Config.Mode)
+			p := *dst
+			for _, dup := range dups {
+				before := takeRowImage(p.Row)
+				for i := 0; i < len(dup.Cell); i++ {
+					if i >= len(p.Cell) {
+						p.Cell = append(p.Cell, dup.Cell[i])
+					} else {
+						p.Cell[i].SetSource(dup.Cell[i].Source(), app.Config.Mode)
+					}
+				}
+				app.recordRowChange(p.Row, before)
+				if p = app.nextOrFetch(p); p == nil {
+					break
 				}
 			}
 		} else if pt == pasteAfter {
-			(*dst).InsertAfter(dup)
-			if (*dst).Term == "" {
-				(*dst).Term = app.Config.Mode.DefaultTerm
+			p := *dst
+			for _, dup := range dups {
+				if p.Term == "" {
+					before := takeRowImage(p.Row)
+					p.Term = app.Config.Mode.DefaultTerm
+					app.recordRowChange(p.Row, before)
+				}
+				p = p.InsertAfter(copyRow(dup))
+				app.recordInsertRow(p)
 			}
 		} else {
-			if (*head).lnum == (*dst).lnum {
+			if (*head).Index() == (*dst).Index() {
 				defer func() {
 					*head = (*dst).Clone()
 				}()
 			}
-			*dst = (*dst).InsertBefore(dup)
+			next := *dst
+			for i, dup := range dups {
+				row := copyRow(dup)
+				if row.Term == "" {
+					row.Term = app.Config.Mode.DefaultTerm
+				}
+				p := next.InsertBefore(row)
+				app.recordInsertRow(p)
+				if i == 0 {
+					*dst = p
+				}
+			}
 		}
 		return nil
 	}
 	return paste
 }
 
+func copyRow(row *uncsv.Row) *uncsv.Row {
+	return &uncsv.Row{
+		Cell: append([]uncsv.Cell{}, row.Cell...),
+		Term: row.Term,
+	}
+}
+
 func (app *Application) yankCurrentRow(src *RowPtr) pasteFunc {
 	dup := &uncsv.Row{Term: src.Term}
 	for _, c := range src.Cell {
@@ -93,21 +132,24 @@ func (app *Application) removeCurrentRow(head, src **RowPtr) pasteFunc {
 	if app.Len() <= 1 {
 		return noPaste
 	}
-	newLnum := (*src).lnum
+	newLnum := (*src).Index()
 	paste := app.makeRowPaster((*src).Row)
 
 	headPrev := (*head).Prev()
 	prevP := (*src).Prev()
+	removedElement := (*src).element
 	removedRow := (*src).Remove()
-	app.removedRows = append(app.removedRows, removedRow)
+	app.removedRows = append(app.removedRows, removedElement)
+	app.recordRemoveRow(newLnum, removedElement)
 	if prevP == nil {
 		(*src) = app.Front()
 	} else if next := prevP.Next(); next != nil {
 		(*src) = next
-		(*src).lnum = newLnum
 	} else {
 		(*src) = prevP
+		before := takeRowImage(prevP.Row)
 		(*src).Term = removedRow.Term
+		app.recordRowChange(prevP.Row, before)
 	}
 	if headPrev == nil {
 		(*head) = app.Front()
@@ -141,6 +183,7 @@ func (app *Application) yankCurrentColumn(col int) pasteFunc {
 			pos++
 		}
 		i := 0
+		var oldCells, newCells []uncsv.Cell
 		for p := app.Front(); p != nil; p = p.Next() {
 			var newSrc []byte
 			if i < len(dup) {
@@ -150,24 +193,37 @@ func (app *Application) yankCurrentColumn(col int) pasteFunc {
 			}
 			i++
 			if pt == pasteOver {
+				oldCells = append(oldCells, p.Cell[pos])
 				p.Cell[pos].SetSource(newSrc, app.Config.Mode)
+				newCells = append(newCells, p.Cell[pos])
 			} else {
 				var newCell uncsv.Cell
 				newCell.SetSource(newSrc, app.Config.Mode)
 				p.InsertCell(pos, newCell, app.Config.Mode)
+				newCells = append(newCells, newCell)
 			}
 		}
+		if pt == pasteOver {
+			app.recordColumnOverwrite(pos, oldCells, newCells)
+		} else {
+			app.recordColumnInsert(pos, newCells)
+		}
 		return nil
 	}
 }
 
 func (app *Application) removeCurrentColumn(col int) pasteFunc {
 	paste := app.yankCurrentColumn(col)
+	removed := []columnCell{}
 	for p := app.Front(); p != nil; p = p.Next() {
 		if len(p.Cell) > 1 && col < len(p.Cell) {
+			removed = append(removed, columnCell{cell: p.Cell[col], ok: true})
 			copy(p.Cell[col:], p.Cell[col+1:])
 			p.Cell = p.Cell[:len(p.Cell)-1]
+		} else {
+			removed = append(removed, columnCell{})
 		}
 	}
+	app.recordColumnRemove(col, removed)
 	return paste
 }
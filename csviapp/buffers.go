@@ -0,0 +1,88 @@
+package csviapp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/hymkor/csvi"
+)
+
+type stream struct {
+	fname string
+	fd    *os.File
+	// lazy keeps the file open after EOF to read the rows again with ReadAt
+	lazy bool
+}
+
+func (s *stream) open() error {
+	if s.fd != nil {
+		return nil
+	}
+	var err error
+	s.fd, err = openShared(s.fname)
+	return err
+}
+
+func (s *stream) Read(r []byte) (int, error) {
+	if err := s.open(); err != nil {
+		return 0, err
+	}
+	n, err := s.fd.Read(r)
+	if err != nil && !s.lazy {
+		s.fd.Close()
+	}
+	return n, err
+}
+
+func (s *stream) ReadAt(r []byte, off int64) (int, error) {
+	if err := s.open(); err != nil {
+		return 0, err
+	}
+	return s.fd.ReadAt(r, off)
+}
+
+// Stat lets csvi read the file as a regular file, parsing the rows in parallel.
+func (s *stream) Stat() (os.FileInfo, error) {
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s.fd.Stat()
+}
+
+// swapPath returns the swap file for the file or "" when it is not used.
+// It is not used with -lazy, which would copy the whole file every time.
+func (f *Options) swapPath(absPath string) string {
+	if f.NoSwap || f.ReadOnly || f.Follow || f.Lazy {
+		return ""
+	}
+	return csvi.SwapPath(absPath)
+}
+
+// buffers returns the files opened as separate buffers
+// with the field separator and the encoding detected for each file.
+func (f *Options) buffers(files []string) ([]csvi.Buffer, error) {
+	if f.Apply != "" {
+		return nil, errors.New("-apply can not be used with two or more files")
+	}
+	buffers := make([]csvi.Buffer, 0, len(files))
+	for _, fname := range files {
+		mode, err := f.modeFor([]string{fname})
+		if err != nil {
+			return nil, err
+		}
+		savePath, err := filepath.Abs(fname)
+		if err != nil {
+			return nil, err
+		}
+		buffers = append(buffers, csvi.Buffer{
+			Name:       fname,
+			Source:     &stream{fname: fname, lazy: f.Lazy},
+			Mode:       mode,
+			SavePath:   savePath,
+			SourcePath: savePath,
+			SwapPath:   f.swapPath(savePath),
+		})
+	}
+	return buffers, nil
+}
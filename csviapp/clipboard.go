@@ -0,0 +1,81 @@
+package csviapp
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"unicode"
+)
+
+// commandClipboard exchanges the text with the system clipboard
+// through external commands like pbcopy/pbpaste or xclip.
+// When one of them is not given, the text is kept in memory instead.
+type commandClipboard struct {
+	copyCmd  []string
+	pasteCmd []string
+	value    string
+}
+
+// splitCommandLine splits the command line into the words separated by spaces.
+// Spaces in single or double quotes are kept like `"C:\Program Files\app.exe"`.
+// Backslashes are not escapes so that the paths of Windows can be written as they are.
+func splitCommandLine(commandLine string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	for _, c := range commandLine {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case unicode.IsSpace(c):
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("%s: the quote is not closed", commandLine)
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+func command(args []string) *exec.Cmd {
+	return exec.Command(args[0], args[1:]...)
+}
+
+func (c *commandClipboard) Write(text string) error {
+	c.value = text
+	if len(c.copyCmd) <= 0 {
+		return nil
+	}
+	cmd := command(c.copyCmd)
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+func (c *commandClipboard) Read() (string, error) {
+	if len(c.pasteCmd) <= 0 {
+		return c.value, nil
+	}
+	output, err := command(c.pasteCmd).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
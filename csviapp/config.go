@@ -0,0 +1,122 @@
+package csviapp
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nyaosorg/go-readline-ny/keys"
+)
+
+// defaultConfigPath returns `$XDG_CONFIG_HOME/csvi/config`
+// (`%APPDATA%\csvi\config` on Windows).
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "csvi", "config")
+}
+
+// keyByName returns the key of a single character like `x`
+// or of the name of go-readline-ny/keys like `C-S`, `M-p` and `F5`.
+func keyByName(name string) (string, error) {
+	if utf8.RuneCountInString(name) == 1 {
+		return name, nil
+	}
+	if code, ok := keys.NameToCode[keys.NormalizeName(name)]; ok {
+		return string(code), nil
+	}
+	return "", fmt.Errorf("%s: unknown key name", name)
+}
+
+// readConfig reads lines like below.
+//
+//	# comment
+//	set NAME=VALUE   (the same as -NAME VALUE unless it is given on the command line)
+//	set NAME         (the same as -NAME)
+//	bind KEY COMMAND (COMMAND is a built-in command name or a command line starting with ":")
+//	unbind KEY
+func (f *Options) readConfig(r io.Reader) error {
+	explicit := map[string]bool{}
+	f.flagSet.Visit(func(fl *flag.Flag) {
+		explicit[fl.Name] = true
+	})
+	sc := bufio.NewScanner(r)
+	for lnum := 1; sc.Scan(); lnum++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if err := f.configLine(line, explicit); err != nil {
+			return fmt.Errorf("line %d: %w", lnum, err)
+		}
+	}
+	return sc.Err()
+}
+
+func (f *Options) configLine(line string, explicit map[string]bool) error {
+	verb, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+	switch verb {
+	case "set":
+		name, value, hasValue := strings.Cut(rest, "=")
+		name = strings.TrimSpace(name)
+		if f.flagSet.Lookup(name) == nil {
+			return fmt.Errorf("%s: no such option", name)
+		}
+		if explicit[name] {
+			return nil
+		}
+		if !hasValue {
+			value = "true"
+		}
+		return f.flagSet.Set(name, value)
+	case "bind":
+		keyName, command, _ := strings.Cut(rest, " ")
+		command = strings.TrimSpace(command)
+		if keyName == "" || command == "" {
+			return errors.New("Usage: bind KEY COMMAND")
+		}
+		key, err := keyByName(keyName)
+		if err != nil {
+			return err
+		}
+		f.keyBindings[key] = command
+	case "unbind":
+		key, err := keyByName(rest)
+		if err != nil {
+			return err
+		}
+		f.keyBindings[key] = ""
+	default:
+		return fmt.Errorf("%s: unknown directive", verb)
+	}
+	return nil
+}
+
+// loadConfig reads the file of -config once.
+func (f *Options) loadConfig() error {
+	if f.keyBindings != nil {
+		return nil
+	}
+	f.keyBindings = map[string]string{}
+	if f.ConfigFile == "" {
+		return nil
+	}
+	fd, err := os.Open(f.ConfigFile)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	if err := f.readConfig(fd); err != nil {
+		return fmt.Errorf("%s: %w", f.ConfigFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package csviapp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// files returns the files to edit.
+// With -diff, the first argument is the old file to compare with.
+func (f *Options) files() []string {
+	args := f.flagSet.Args()
+	if f.Diff && len(args) > 0 {
+		return args[1:]
+	}
+	return args
+}
+
+// readDiffBase reads the old file of -diff with its own field separator and encoding.
+func (f *Options) readDiffBase() ([]*uncsv.Row, error) {
+	if f.flagSet.NArg() != 2 {
+		return nil, errors.New("-diff requires two files: OLD NEW")
+	}
+	path := f.flagSet.Arg(0)
+	mode, err := f.modeFor([]string{path})
+	if err != nil {
+		return nil, err
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	br := bufio.NewReader(fd)
+	var rows []*uncsv.Row
+	for {
+		row, err := uncsv.ReadLine(br, mode)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if !row.IsZero() {
+			rows = append(rows, row)
+		}
+		if err != nil {
+			return rows, nil
+		}
+	}
+}
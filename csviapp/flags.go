@@ -2,13 +2,14 @@ package csviapp
 
 import (
 	"flag"
+	"os"
 	"path/filepath"
 
 	"github.com/hymkor/struct2flag"
 )
 
 type Options struct {
-	CellWidth     string `flag:"w,set the \x60widths\x60 of cells like '-w DefaultWidth,COL0:WIDTH0,COL1:WIDTH1,...'. COLn is the index starting from 0"`
+	CellWidth     string `flag:"w,set the \x60widths\x60 of cells like '-w DefaultWidth,COL0:WIDTH0,COL1:WIDTH1,...'. COLn is the index starting from 0. 'auto' or 'auto:MIN:MAX' fits widths to the contents"`
 	Header        uint   `flag:"h,the number of row-header"`
 	Tsv           bool   `flag:"t,use TAB as field-separator"`
 	Csv           bool   `flag:"c,use Comma as field-separator"`
@@ -21,6 +22,9 @@ type Options struct {
 	Utf16be       bool   `flag:"16be,Force read/write as UTF-16BE"`
 	FixColumn     bool   `flag:"fixcol,Do not insert/delete a column"`
 	ReadOnly      bool   `flag:"readonly,Read Only Mode"`
+	Follow        bool   `flag:"follow,keep reading the rows appended to the file or STDIN after the end like 'tail -f' (read only)"`
+	Lazy          bool   `flag:"lazy,keep only the rows on the screen and the rows changed in memory and read the others again from the file on demand for huge files"`
+	NoSwap        bool   `flag:"noswap,do not write the swap file (.FILENAME.csvi-swp) to recover unsaved changes"`
 	ProtectHeader bool   `flag:"p,Protect the header line"`
 	Title         string `flag:"title,Set title string"`
 	ReverseVideo  bool   `flag:"rv,Enable reverse-video display (invert foreground and background colors)"`
@@ -31,7 +35,17 @@ type Options struct {
 	Version       bool   `flag:"version,print version and exit"`
 	Lf            bool   `flag:"lf,use LF as the default line ending for newly added lines"`
 	CrLf          bool   `flag:"crlf,use CRLF as the default line ending for newly added lines"`
+	FreezeCol     uint   `flag:"freezecol,the number of leading columns which do not scroll horizontally"`
+	ClipCsv       bool   `flag:"clipcsv,use the field separator of the data instead of TAB for the clipboard"`
+	CopyCmd       string `flag:"copycmd,command to write the clipboard with the copied text from STDIN (for example: pbcopy, clip.exe, 'xclip -selection clipboard')"`
+	PasteCmd      string `flag:"pastecmd,command to read the clipboard from its STDOUT (for example: pbpaste, 'xclip -selection clipboard -o')"`
+	NoOsc52       bool   `flag:"noosc52,do not send the copied text to the terminal with OSC 52"`
+	Diff          bool   `flag:"diff,compare the first file (old) with the second file (new) and edit the new one"`
+	DiffKey       uint   `flag:"diffkey,the column (counted from 1) whose values pair the rows for -diff instead of the order of rows"`
+	Apply         string `flag:"apply,apply the patch (JSON lines written from the review of changes) to the file as unsaved changes"`
+	ConfigFile    string `flag:"config,configuration file of key bindings and default options (default: $XDG_CONFIG_HOME/csvi/config)"`
 	flagSet       *flag.FlagSet
+	keyBindings   map[string]string
 }
 
 func NewOptions() *Options {
@@ -51,7 +65,15 @@ func Run() error {
 	f := NewOptions().Bind(flag.CommandLine)
 	flag.Parse()
 
-	if args := flag.Args(); len(args) >= 1 {
+	if f.ConfigFile == "" {
+		if path := defaultConfigPath(); path != "" {
+			if _, err := os.Stat(path); err == nil {
+				f.ConfigFile = path
+			}
+		}
+	}
+
+	if args := f.files(); len(args) >= 1 {
 		var err error
 		f.SavePath, err = filepath.Abs(args[0])
 		if err != nil {
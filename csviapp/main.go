@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"github.com/mattn/go-colorable"
@@ -23,6 +24,11 @@ var (
 )
 
 func (f *Options) mode() (*uncsv.Mode, error) {
+	return f.modeFor(f.files())
+}
+
+// modeFor returns the mode for the files.
+func (f *Options) modeFor(files []string) (*uncsv.Mode, error) {
 	mode := &uncsv.Mode{}
 	if f.Iana != "" {
 		if err := mode.SetEncoding(f.Iana); err != nil {
@@ -47,7 +53,7 @@ func (f *Options) mode() (*uncsv.Mode, error) {
 		mode.SetUTF16BE()
 	}
 	delimiterCount := 0
-	if len(f.flagSet.Args()) <= 0 && isatty.IsTerminal(uintptr(os.Stdin.Fd())) {
+	if len(files) <= 0 && isatty.IsTerminal(uintptr(os.Stdin.Fd())) {
 		// Start with one empty line
 		if f.Tsv {
 			mode.Comma = '\t'
@@ -58,8 +64,7 @@ func (f *Options) mode() (*uncsv.Mode, error) {
 		}
 	} else {
 		mode.Comma = ','
-		args := f.flagSet.Args()
-		if len(args) >= 1 && !strings.HasSuffix(strings.ToLower(args[0]), ".csv") {
+		if len(files) >= 1 && !strings.HasSuffix(strings.ToLower(files[0]), ".csv") {
 			mode.Comma = '\t'
 		}
 		if f.Tsv {
@@ -89,6 +94,19 @@ func (f *Options) mode() (*uncsv.Mode, error) {
 	return mode, nil
 }
 
+func readPatchFile(path string) ([]csvi.Change, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	patch, err := csvi.ReadPatch(fd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return patch, nil
+}
+
 func (f *Options) setGlobalColor() {
 	if f.ReverseVideo || csvi.IsRevertVideoWithEnv() {
 		csvi.RevertColor()
@@ -98,14 +116,15 @@ func (f *Options) setGlobalColor() {
 }
 
 func (f *Options) dataSourceAndTtyOut() (io.Reader, io.Writer) {
-	if len(f.flagSet.Args()) <= 0 {
+	if len(f.files()) <= 0 {
 		ttyOut := colorable.NewColorableStderr()
 		if isatty.IsTerminal(os.Stdin.Fd()) {
 			return nil, ttyOut
 		}
 		return os.Stdin, ttyOut
 	}
-	return multiFileReader(f.flagSet.Args()...),
+	// two or more files are opened by RunInOut as separate buffers
+	return &stream{fname: f.files()[0], lazy: f.Lazy},
 		colorable.NewColorableStdout()
 }
 
@@ -124,6 +143,9 @@ func (f *Options) Run() error {
 	if f.Version {
 		return nil
 	}
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
 	disable := colorable.EnableColorsStdout(nil)
 	if disable != nil {
 		defer disable()
@@ -166,10 +188,28 @@ func (f *Options) RunInOut(dataSource io.Reader, ttyOut io.Writer) error {
 	io.WriteString(ttyOut, ansi.CURSOR_OFF)
 	defer io.WriteString(ttyOut, ansi.CURSOR_ON)
 
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
 	mode, err := f.mode()
 	if err != nil {
 		return err
 	}
+	var diffBase []*uncsv.Row
+	if f.Diff {
+		diffBase, err = f.readDiffBase()
+		if err != nil {
+			return err
+		}
+	}
+
+	var patch []csvi.Change
+	if f.Apply != "" {
+		patch, err = readPatchFile(f.Apply)
+		if err != nil {
+			return err
+		}
+	}
 
 	cw := csvi.NewCellWidth()
 	if err := cw.Parse(f.CellWidth); err != nil {
@@ -185,7 +225,23 @@ func (f *Options) RunInOut(dataSource io.Reader, ttyOut io.Writer) error {
 		extEditor = f.callExtEditor
 	}
 
-	_, err = csvi.Config{
+	var clipboard csvi.Clipboard
+	copyCmd, err := splitCommandLine(f.CopyCmd)
+	if err != nil {
+		return fmt.Errorf("-copycmd: %w", err)
+	}
+	pasteCmd, err := splitCommandLine(f.PasteCmd)
+	if err != nil {
+		return fmt.Errorf("-pastecmd: %w", err)
+	}
+	if len(copyCmd) > 0 || len(pasteCmd) > 0 {
+		clipboard = &commandClipboard{
+			copyCmd:  copyCmd,
+			pasteCmd: pasteCmd,
+		}
+	}
+
+	cfg := csvi.Config{
 		Mode:          mode,
 		Pilot:         f.pilot(),
 		CellWidth:     cw,
@@ -197,7 +253,34 @@ func (f *Options) RunInOut(dataSource io.Reader, ttyOut io.Writer) error {
 		OutputSep:     f.OutputSep,
 		SavePath:      f.SavePath,
 		ExtEditor:     extEditor,
-	}.Edit(dataSource, ttyOut)
-
+		Clipboard:     clipboard,
+		ClipboardCSV:  f.ClipCsv,
+		NoOSC52:       f.NoOsc52,
+		FreezeColumns: int(f.FreezeCol),
+		KeyBindings:   f.keyBindings,
+		DiffBase:      diffBase,
+		DiffKeyColumn: int(f.DiffKey),
+		Patch:         patch,
+		Follow:        f.Follow,
+		Lazy:          f.Lazy && len(f.files()) > 0,
+	}
+	files := f.files()
+	if len(files) == 1 {
+		path, err := filepath.Abs(files[0])
+		if err != nil {
+			return err
+		}
+		cfg.SourcePath = path
+		cfg.SwapPath = f.swapPath(path)
+	}
+	if len(files) >= 2 {
+		buffers, err := f.buffers(files)
+		if err != nil {
+			return err
+		}
+		_, err = cfg.EditBuffers(buffers, ttyOut)
+		return err
+	}
+	_, err = cfg.Edit(dataSource, ttyOut)
 	return err
 }
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package csviapp
+
+import (
+	"os"
+)
+
+// openShared opens the file to read. Saving can replace it while it is open.
+func openShared(name string) (*os.File, error) {
+	return os.Open(name)
+}
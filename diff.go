@@ -0,0 +1,485 @@
+package csvi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+type diffKind int
+
+const (
+	diffSame diffKind = iota
+	diffAdded
+	diffRemoved
+	diffChanged
+)
+
+var (
+	diffAddedColor   = "\x1B[32m"
+	diffRemovedColor = "\x1B[31m"
+	diffChangedColor = "\x1B[33m"
+)
+
+// diffEntry is the row of the old file paired with a row of the buffer.
+type diffEntry struct {
+	old *uncsv.Row
+	// ghost is true for the row which exists only in the old file.
+	// It is shown but neither edited nor saved until it is restored.
+	ghost bool
+}
+
+// diffMaxEdits limits the number of the differences searched
+// because the memory for them grows with the square of it.
+const diffMaxEdits = 2000
+
+func rowKey(row *uncsv.Row) string {
+	texts := make([]string, 0, len(row.Cell))
+	for _, c := range row.Cell {
+		texts = append(texts, c.Text())
+	}
+	return strings.Join(texts, "\x1F")
+}
+
+// commonPairs returns the pairs of the indices of the same elements of a and b
+// in increasing order with the O(ND) algorithm of Myers.
+// ok is false when there are more than limit differences.
+func commonPairs(a, b []string, limit int) (pairs [][2]int, ok bool) {
+	n, m := len(a), len(b)
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		trace = append(trace, append([]int{}, v[offset-d:offset+d+1]...))
+	}
+	if !found {
+		return nil, false
+	}
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1 // moved down: inserted b[prevY]
+		} else {
+			prevK = k - 1 // moved right: deleted a[prevX]
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+		startX, startY := prevX, prevY+1
+		if prevK == k-1 {
+			startX, startY = prevX+1, prevY
+		}
+		for x > startX && y > startY {
+			x--
+			y--
+			pairs = append(pairs, [2]int{x, y})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		pairs = append(pairs, [2]int{x, y})
+	}
+	for i, j := 0, len(pairs)-1; i < j; i, j = i+1, j-1 {
+		pairs[i], pairs[j] = pairs[j], pairs[i]
+	}
+	return pairs, true
+}
+
+// alignByOrder pairs the rows by the order of lines.
+// The rows removed and added between the same rows are paired
+// as changed rows from the top.
+// It returns the index of the old row for each new row (-1 for added)
+// and the indices of the removed old rows to be put before each new row.
+// ghosts[len(newRows)] are put after the last row.
+func alignByOrder(oldRows, newRows []*uncsv.Row) (match []int, ghosts [][]int) {
+	n, m := len(oldRows), len(newRows)
+	// the common head and tail are skipped before searching the differences.
+	head := 0
+	for head < n && head < m && rowKey(oldRows[head]) == rowKey(newRows[head]) {
+		head++
+	}
+	tail := 0
+	for tail < n-head && tail < m-head && rowKey(oldRows[n-1-tail]) == rowKey(newRows[m-1-tail]) {
+		tail++
+	}
+	a := make([]string, 0, n-head-tail)
+	for _, row := range oldRows[head : n-tail] {
+		a = append(a, rowKey(row))
+	}
+	b := make([]string, 0, m-head-tail)
+	for _, row := range newRows[head : m-tail] {
+		b = append(b, rowKey(row))
+	}
+	pairs, ok := commonPairs(a, b, diffMaxEdits)
+	if !ok {
+		// too different: compare the rows at the same positions
+		pairs = nil
+	}
+	all := make([][2]int, 0, head+len(pairs)+tail+1)
+	for i := 0; i < head; i++ {
+		all = append(all, [2]int{i, i})
+	}
+	for _, p := range pairs {
+		all = append(all, [2]int{p[0] + head, p[1] + head})
+	}
+	for i := tail; i > 0; i-- {
+		all = append(all, [2]int{n - i, m - i})
+	}
+	all = append(all, [2]int{n, m}) // sentinel
+
+	match = make([]int, m)
+	ghosts = make([][]int, m+1)
+	i, j := 0, 0
+	for _, p := range all {
+		// old rows i..p[0] and new rows j..p[1] are different
+		for i < p[0] && j < p[1] {
+			match[j] = i
+			i++
+			j++
+		}
+		for ; i < p[0]; i++ {
+			ghosts[j] = append(ghosts[j], i)
+		}
+		for ; j < p[1]; j++ {
+			match[j] = -1
+		}
+		if p[0] < n {
+			match[j] = i
+			i++
+			j++
+		}
+	}
+	return
+}
+
+// alignByKey pairs the rows which have the same value in the column.
+// Header lines are paired by their positions.
+// A removed row is put after the row paired with the old row before it.
+func alignByKey(oldRows, newRows []*uncsv.Row, col, headerLines int) (match []int, ghosts [][]int) {
+	keyOf := func(row *uncsv.Row) string {
+		if col < len(row.Cell) {
+			return row.Cell[col].Text()
+		}
+		return ""
+	}
+	index := map[string]int{}
+	for i := len(oldRows) - 1; i >= headerLines; i-- {
+		index[keyOf(oldRows[i])] = i
+	}
+	match = make([]int, len(newRows))
+	used := make([]bool, len(oldRows))
+	newOf := make([]int, len(oldRows))
+	for i := range newOf {
+		newOf[i] = -1
+	}
+	for j, row := range newRows {
+		match[j] = -1
+		if j < headerLines {
+			if j < len(oldRows) {
+				match[j] = j
+			}
+		} else if i, ok := index[keyOf(row)]; ok && !used[i] {
+			match[j] = i
+		}
+		if i := match[j]; i >= 0 {
+			used[i] = true
+			newOf[i] = j
+		}
+	}
+	ghosts = make([][]int, len(newRows)+1)
+	next := headerLines // where the removed rows are put
+	if next > len(newRows) {
+		next = len(newRows)
+	}
+	for i := range oldRows {
+		if used[i] {
+			next = newOf[i] + 1
+			continue
+		}
+		ghosts[next] = append(ghosts[next], i)
+	}
+	return
+}
+
+// applyDiff compares all the rows with Config.DiffBase
+// and puts the rows only in the old file as ghost rows.
+func (app *Application) applyDiff() error {
+	ctx, cancel := app.withSlowOperation("Comparing...")
+	app.fetchAll(ctx)
+	err := ctx.Err()
+	cancel()
+	if err != nil {
+		return errors.New("Comparing interrupted")
+	}
+	rows := make([]*RowPtr, 0, app.Len())
+	newRows := make([]*uncsv.Row, 0, app.Len())
+	for p := app.Front(); p != nil; p = p.Next() {
+		rows = append(rows, p)
+		newRows = append(newRows, p.Row)
+	}
+	var match []int
+	var ghosts [][]int
+	if app.DiffKeyColumn > 0 {
+		match, ghosts = alignByKey(app.DiffBase, newRows, app.DiffKeyColumn-1, app.HeaderLines)
+	} else {
+		match, ghosts = alignByOrder(app.DiffBase, newRows)
+	}
+	app.diff = map[*uncsv.Row]*diffEntry{}
+	for j, i := range match {
+		if i >= 0 {
+			app.diff[newRows[j]] = &diffEntry{old: app.DiffBase[i]}
+		}
+	}
+	newGhost := func(i int) *uncsv.Row {
+		old := app.DiffBase[i]
+		texts := make([]string, 0, len(old.Cell))
+		for _, c := range old.Cell {
+			texts = append(texts, c.Text())
+		}
+		row := uncsv.NewRowFromStringSlice(app.Mode, texts)
+		row.MarkAsSave()
+		app.diff[&row] = &diffEntry{old: old, ghost: true}
+		return &row
+	}
+	for j, list := range ghosts {
+		if j < len(rows) {
+			for _, i := range list {
+				rows[j].InsertBefore(newGhost(i))
+			}
+		} else {
+			last := app.Back()
+			for k := len(list) - 1; k >= 0; k-- {
+				last.InsertAfter(newGhost(list[k]))
+			}
+		}
+	}
+	return nil
+}
+
+func (app *Application) diffEntryOf(row *uncsv.Row) *diffEntry {
+	if app.diff == nil {
+		return nil
+	}
+	return app.diff[row]
+}
+
+func (app *Application) isGhost(row *uncsv.Row) bool {
+	e := app.diffEntryOf(row)
+	return e != nil && e.ghost
+}
+
+// ghosts returns the line numbers of the ghost rows in order.
+// They are counted again after the rows are edited.
+func (app *Application) ghosts() []int {
+	if app.ghostLnums == nil {
+		app.ghostLnums = []int{}
+		app.visitRows(0, func(lnum int, row *uncsv.Row) bool {
+			if app.isGhost(row) {
+				app.ghostLnums = append(app.ghostLnums, lnum)
+			}
+			return true
+		})
+	}
+	return app.ghostLnums
+}
+
+func (app *Application) invalidateGhosts() {
+	app.ghostLnums = nil
+}
+
+// savedPosition returns the row number of the cursor and the number of the rows
+// counted without the ghost rows, which are not saved.
+// On a ghost row, it is the number which the row gets when it is restored.
+func (app *Application) savedPosition() (lnum, total int) {
+	ghosts := app.ghosts()
+	index := app.cursorRow.Index()
+	return index - sort.SearchInts(ghosts, index) + 1, app.Len() - len(ghosts)
+}
+
+func (app *Application) diffKindOf(row *uncsv.Row) diffKind {
+	if app.diff == nil {
+		return diffSame
+	}
+	e := app.diff[row]
+	if e == nil {
+		return diffAdded
+	}
+	if e.ghost {
+		return diffRemoved
+	}
+	if len(e.old.Cell) != len(row.Cell) {
+		return diffChanged
+	}
+	for i := range row.Cell {
+		if row.Cell[i].Text() != e.old.Cell[i].Text() {
+			return diffChanged
+		}
+	}
+	return diffSame
+}
+
+// isCellChanged is true when the cell differs from the old file.
+func (app *Application) isCellChanged(row *uncsv.Row, col int) bool {
+	e := app.diffEntryOf(row)
+	if e == nil || e.ghost || col >= len(row.Cell) {
+		return false
+	}
+	return col >= len(e.old.Cell) || e.old.Cell[col].Text() != row.Cell[col].Text()
+}
+
+func (app *Application) diffColor(row *uncsv.Row) string {
+	switch app.diffKindOf(row) {
+	case diffAdded:
+		return diffAddedColor
+	case diffRemoved:
+		return diffRemovedColor
+	case diffChanged:
+		return diffChangedColor
+	}
+	return ""
+}
+
+func (app *Application) diffStatus() string {
+	switch app.diffKindOf(app.cursorRow.Row) {
+	case diffAdded:
+		return "[added]"
+	case diffRemoved:
+		return "[removed]"
+	case diffChanged:
+		if e := app.diff[app.cursorRow.Row]; app.cursorCol < len(e.old.Cell) && app.isCellChanged(app.cursorRow.Row, app.cursorCol) {
+			return fmt.Sprintf("[changed from %q]", replaceTable.Replace(e.old.Cell[app.cursorCol].Text()))
+		}
+		return "[changed]"
+	}
+	return ""
+}
+
+// nextHunk moves to the first row of the next block of differences.
+func (app *Application) nextHunk() error {
+	p := app.cursorRow
+	for p != nil && app.diffKindOf(p.Row) != diffSame {
+		p = app.nextVisible(p)
+	}
+	for p != nil && app.diffKindOf(p.Row) == diffSame {
+		p = app.nextVisible(p)
+	}
+	if p == nil {
+		return errors.New("No more differences")
+	}
+	app.rememberJump()
+	app.cursorRow = p
+	return nil
+}
+
+// prevHunk moves to the first row of the previous block of differences.
+func (app *Application) prevHunk() error {
+	p := app.cursorRow
+	for p != nil && app.diffKindOf(p.Row) != diffSame {
+		p = app.prevVisible(p)
+	}
+	for p != nil && app.diffKindOf(p.Row) == diffSame {
+		p = app.prevVisible(p)
+	}
+	if p == nil {
+		return errors.New("No more differences")
+	}
+	for prev := app.prevVisible(p); prev != nil && app.diffKindOf(prev.Row) != diffSame; prev = app.prevVisible(prev) {
+		p = prev
+	}
+	app.rememberJump()
+	app.cursorRow = p
+	return nil
+}
+
+// diffGet copies the current cell of the old file into the buffer.
+// A removed row is restored and an added row is deleted.
+// With wholeRow, all the cells of a changed row are copied.
+// Copying is one-way because only the new file is edited;
+// run with the files swapped to copy the cells the other way.
+func (app *Application) diffGet(wholeRow bool) error {
+	if app.diff == nil {
+		return errors.New("Not in the diff mode")
+	}
+	if app.ReadOnly {
+		return errors.New(msgReadOnly)
+	}
+	row := app.cursorRow.Row
+	switch app.diffKindOf(row) {
+	case diffRemoved:
+		// the last row of the file may not have a line terminator
+		for p := app.cursorRow.Prev(); p != nil; p = p.Prev() {
+			if app.isGhost(p.Row) {
+				continue
+			}
+			if p.Term == "" {
+				before := takeRowImage(p.Row)
+				p.Term = row.Term
+				app.recordRowChange(p.Row, before)
+			}
+			break
+		}
+		e := app.diff[row]
+		e.ghost = false
+		app.record(func() { e.ghost = true }, func() { e.ghost = false })
+	case diffAdded:
+		if m := app.checkWriteProtect(app.cursorRow); m != "" {
+			return errors.New(m)
+		}
+		app.removeCurrentRow(&app.startRow, &app.cursorRow)
+	case diffChanged:
+		if m := app.checkWriteProtect(app.cursorRow); m != "" {
+			return errors.New(m)
+		}
+		old := app.diff[row].old
+		before := takeRowImage(row)
+		if wholeRow {
+			for len(row.Cell) > len(old.Cell) {
+				row.Delete(len(row.Cell) - 1)
+			}
+			for i, c := range old.Cell {
+				if i >= len(row.Cell) {
+					row.Insert(i, c.Text(), app.Mode)
+				} else if row.Cell[i].Text() != c.Text() {
+					row.Replace(i, c.Text(), app.Mode)
+				}
+			}
+		} else if app.cursorCol < len(old.Cell) {
+			row.Replace(app.cursorCol, old.Cell[app.cursorCol].Text(), app.Mode)
+		} else {
+			return errors.New("The old row does not have this cell")
+		}
+		app.recordRowChange(row, before)
+	default:
+		return nil
+	}
+	app.setHardDirty()
+	app.repaint()
+	app.clearCache()
+	return nil
+}
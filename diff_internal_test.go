@@ -0,0 +1,87 @@
+package csvi
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+func TestCommonPairs(t *testing.T) {
+	a := strings.Split("abcabba", "")
+	b := strings.Split("cbabac", "")
+	pairs, ok := commonPairs(a, b, 100)
+	if !ok {
+		t.Fatal("commonPairs failed")
+	}
+	// the length of the longest common subsequence of them is 4
+	if len(pairs) != 4 {
+		t.Fatalf("pairs: %v", pairs)
+	}
+	for i, p := range pairs {
+		if a[p[0]] != b[p[1]] {
+			t.Fatalf("pairs: %v: a[%d] != b[%d]", pairs, p[0], p[1])
+		}
+		if i > 0 && (p[0] <= pairs[i-1][0] || p[1] <= pairs[i-1][1]) {
+			t.Fatalf("pairs: %v: not increasing", pairs)
+		}
+	}
+	if _, ok := commonPairs(a, b, 2); ok {
+		t.Fatal("commonPairs must fail with too small limit")
+	}
+}
+
+func TestCommonPairsEmpty(t *testing.T) {
+	for _, c := range [][2]string{{"", ""}, {"abc", ""}, {"", "abc"}, {"abc", "abc"}} {
+		a := strings.Split(c[0], "")
+		b := strings.Split(c[1], "")
+		pairs, ok := commonPairs(a, b, 10)
+		if !ok {
+			t.Fatalf("%q %q: failed", c[0], c[1])
+		}
+		if c[0] == c[1] && len(pairs) != len(a) {
+			t.Fatalf("%q %q: %v", c[0], c[1], pairs)
+		}
+		if c[0] != c[1] && len(pairs) != 0 {
+			t.Fatalf("%q %q: %v", c[0], c[1], pairs)
+		}
+	}
+}
+
+func TestSavedPosition(t *testing.T) {
+	mode := &uncsv.Mode{Comma: ','}
+	rows := func(texts ...string) []*uncsv.Row {
+		var result []*uncsv.Row
+		for _, s := range texts {
+			row := uncsv.NewRowFromStringSlice(mode, []string{s})
+			result = append(result, &row)
+		}
+		return result
+	}
+	app := (&Config{Mode: mode, DiffBase: rows("a", "b", "c", "d")}).newApplication(io.Discard)
+	for _, row := range rows("a", "c", "e") {
+		app.push(row)
+	}
+	if err := app.applyDiff(); err != nil {
+		t.Fatal(err.Error())
+	}
+	app.cursorRow = app.Front()
+	app.startRow = app.Front()
+	// a, b(ghost), c, d(ghost), e
+	for lnum, expect := range []int{1, 2, 2, 3, 3} {
+		app.cursorRow = app.seek(lnum)
+		if n, total := app.savedPosition(); n != expect || total != 3 {
+			t.Fatalf("row %d: expect %d/3, but %d/%d", lnum, expect, n, total)
+		}
+	}
+	// the ghost row restored is counted
+	app.cursorRow = app.seek(1)
+	if err := app.diffGet(true); err != nil {
+		t.Fatal(err.Error())
+	}
+	app.cursorRow = app.seek(4)
+	if n, total := app.savedPosition(); n != 4 || total != 4 {
+		t.Fatalf("after diffGet: expect 4/4, but %d/%d", n, total)
+	}
+}
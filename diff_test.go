@@ -0,0 +1,62 @@
+package csvi_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testDiff(t *testing.T, oldSource, newSource, process, result string, options ...string) {
+	t.Helper()
+	oldPath := makeSource(t, "old.csv", oldSource)
+	newPath := makeSource(t, "new.csv", newSource)
+	path := filepath.Join(t.TempDir(), "test.csv")
+	args := make([]string, 0, len(options)+6)
+	args = append(args, options...)
+	args = append(args, "-diff", "-auto", fmt.Sprintf("%s|w|%s|q|y", process, path), oldPath, newPath)
+	testRun(t, strings.NewReader(newSource), args...)
+	checkResult(t, path, result)
+}
+
+func TestDiffByOrder(t *testing.T) {
+	oldSource := "a\nb\nc\nd\n"
+	newSource := "a\nc\nd\ne\n"
+	// the removed row is not saved
+	testDiff(t, oldSource, newSource, "", newSource, "-h", "0")
+	testDiff(t, oldSource, newSource, "j|r|X", newSource, "-h", "0")
+	// D restores the removed row and deletes the added row
+	testDiff(t, oldSource, newSource, "}|D", "a\nb\nc\nd\ne\n", "-h", "0")
+	testDiff(t, oldSource, newSource, "}|}|D", "a\nc\nd\n", "-h", "0")
+	testDiff(t, oldSource, newSource, "G|{|{|D", "a\nb\nc\nd\ne\n", "-h", "0")
+	testDiff(t, oldSource, newSource, "}|D|u", newSource, "-h", "0")
+}
+
+func TestDiffChangedCell(t *testing.T) {
+	oldSource := "id,name\n1,apple\n2,banana\n3,cherry\n"
+	newSource := "id,name\n1,apple\n2,BANANA\n4,durian\n"
+	testDiff(t, oldSource, newSource, "}|l|D", "id,name\n1,apple\n2,banana\n4,durian\n")
+	testDiff(t, oldSource, newSource, "}|j|:|diffget", "id,name\n1,apple\n2,BANANA\n3,cherry\n")
+}
+
+func TestDiffByKey(t *testing.T) {
+	oldSource := "id,v\n1,a\n2,b\n3,c\n"
+	newSource := "id,v\n3,c\n1,A\n4,d\n"
+	testDiff(t, oldSource, newSource, "}|l|D", "id,v\n3,c\n1,a\n4,d\n", "-diffkey", "1")
+	testDiff(t, oldSource, newSource, "}|j|D", "id,v\n3,c\n1,A\n2,b\n4,d\n", "-diffkey", "1")
+}
+
+func TestDiffSeparators(t *testing.T) {
+	// the old file is TSV with BOM and the new one is CSV
+	oldPath := makeSource(t, "old.tsv", "\xEF\xBB\xBFa\tb\nc\td\n")
+	newSource := "a,b\nc,X\n"
+	newPath := makeSource(t, "new.csv", newSource)
+	path := filepath.Join(t.TempDir(), "test.csv")
+	testRun(t, strings.NewReader(newSource),
+		"-diff", "-auto", fmt.Sprintf("}|l|D|w|%s|q|y", path), oldPath, newPath)
+	checkResult(t, path, "a,b\nc,d\n")
+}
+
+func TestDiffRestoreLastRow(t *testing.T) {
+	testDiff(t, "a\nb\nc", "a\nb", "G|D", "a\nb\nc\n", "-h", "0")
+}
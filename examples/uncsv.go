@@ -0,0 +1,46 @@
+//go:build example
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+func run() error {
+	r := uncsv.NewReader(os.Stdin, &uncsv.Mode{Comma: ','})
+	w := uncsv.NewWriter(os.Stdout, r.Mode())
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var perr *uncsv.ParseError
+		if errors.As(err, &perr) {
+			fmt.Fprintf(os.Stderr, "line %d, column %d: %v\n", perr.Line, perr.Column, perr.Err)
+		} else if err != nil {
+			w.Flush()
+			return err
+		}
+		// only the changed cell is rewritten; the others keep their quotes and spaces
+		if len(row.Cell) > 1 {
+			row.Replace(1, strings.ToUpper(row.Cell[1].Text()), r.Mode())
+		}
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,322 @@
+package csvi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hymkor/csvi/candidate"
+)
+
+// CommandEventArgs is given to the commands called from the `:` prompt.
+type CommandEventArgs struct {
+	*Application
+
+	// Name is the name of the command without "!"
+	Name string
+
+	// Bang is true when the name is followed by "!" like `:q!`
+	Bang bool
+
+	// Args are the words after the name
+	Args []string
+}
+
+var builtinCommands = map[string]func(*CommandEventArgs) (*CommandResult, error){
+	"w":        cmdExWrite,
+	"write":    cmdExWrite,
+	"q":        cmdExQuit,
+	"quit":     cmdExQuit,
+	"wq":       cmdExWriteQuit,
+	"x":        cmdExWriteQuit,
+	"goto":     cmdExGoto,
+	"set":      cmdExSet,
+	"sort":     cmdExSort,
+	"filter":   cmdExFilter,
+	"undo":     cmdExUndo,
+	"redo":     cmdExRedo,
+	"diffget":  cmdExDiffGet,
+	"changes":  cmdExChanges,
+	"problems": cmdExProblems,
+	"bn":       cmdExBufferNext,
+	"bnext":    cmdExBufferNext,
+	"bp":       cmdExBufferPrevious,
+	"bprev":    cmdExBufferPrevious,
+	"b":        cmdExBuffer,
+	"buffer":   cmdExBuffer,
+	"ls":       cmdExBuffers,
+	"buffers":  cmdExBuffers,
+	"patch":    cmdExPatch,
+}
+
+func messageResult(message string) (*CommandResult, error) {
+	return &CommandResult{Message: message}, nil
+}
+
+func cmdExWrite(e *CommandEventArgs) (*CommandResult, error) {
+	if len(e.Args) > 1 {
+		return nil, errors.New("Too many file names")
+	}
+	fname := e.getSavePath()
+	if len(e.Args) == 1 {
+		fname = e.Args[0]
+	}
+	message, err := e.cmdSaveAs(fname)
+	if err != nil {
+		return nil, err
+	}
+	return messageResult(message)
+}
+
+func cmdExQuit(e *CommandEventArgs) (*CommandResult, error) {
+	if e.Bang {
+		return &CommandResult{Quit: true}, nil
+	}
+	rc, err := e.cmdQuit()
+	if err != nil {
+		return nil, err
+	}
+	return &CommandResult{Quit: rc != nil}, nil
+}
+
+// cmdExWriteQuit saves the current buffer if changed and quits.
+// The other buffers changed are asked as `q` does.
+func cmdExWriteQuit(e *CommandEventArgs) (*CommandResult, error) {
+	if !e.ReadOnly && e.IsDirty() {
+		if _, err := cmdExWrite(e); err != nil {
+			return nil, err
+		}
+	}
+	if e.Bang {
+		return &CommandResult{Quit: true}, nil
+	}
+	return cmdExQuit(e)
+}
+
+// cmdExGoto moves the cursor to `ROW [COL]`, `ROW,COL`, `N%` or a column name.
+func cmdExGoto(e *CommandEventArgs) (*CommandResult, error) {
+	if len(e.Args) <= 0 {
+		return nil, errors.New("Usage: goto ROW [COL]")
+	}
+	if err := e.gotoTarget(strings.Join(e.Args, " ")); err != nil {
+		return nil, err
+	}
+	return messageResult("")
+}
+
+var booleanOptions = map[string]func(*Config) *bool{
+	"readonly": func(cfg *Config) *bool { return &cfg.ReadOnly },
+	"fixcol":   func(cfg *Config) *bool { return &cfg.FixColumn },
+	"protect":  func(cfg *Config) *bool { return &cfg.ProtectHeader },
+}
+
+func (app *Application) optionsString() string {
+	var buffer strings.Builder
+	names := make([]string, 0, len(booleanOptions))
+	for name := range booleanOptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !*booleanOptions[name](app.Config) {
+			buffer.WriteString("no")
+		}
+		buffer.WriteString(name)
+		buffer.WriteByte(' ')
+	}
+	fmt.Fprintf(&buffer, "header=%d freezecol=%d ofs=%q", app.HeaderLines, app.frozenCols, app.OutputSep)
+	return buffer.String()
+}
+
+// setOption sets the option given like `NAME=VALUE`, `NAME` or `noNAME`.
+func (app *Application) setOption(arg string) error {
+	name, value, hasValue := strings.Cut(arg, "=")
+	if app.Follow && (name == "noreadonly" || name == "readonly" && hasValue) {
+		return errors.New("readonly can not be changed while following the data")
+	}
+	if get, ok := booleanOptions[name]; ok {
+		if !hasValue {
+			*get(app.Config) = true
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		*get(app.Config) = b
+		return nil
+	}
+	if get, ok := booleanOptions[strings.TrimPrefix(name, "no")]; ok && !hasValue {
+		*get(app.Config) = false
+		return nil
+	}
+	if !hasValue {
+		return fmt.Errorf("%s: unknown option or value missing", arg)
+	}
+	switch name {
+	case "ofs":
+		app.OutputSep = value
+	case "header", "h":
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		app.HeaderLines = int(n)
+		app.invalidateFilterCount()
+	case "freezecol":
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		app.frozenCols = int(n)
+		app.FreezeColumns = int(n)
+	case "width", "w":
+		return app.CellWidth.Parse(value)
+	default:
+		return fmt.Errorf("%s: unknown option", name)
+	}
+	return nil
+}
+
+func cmdExSet(e *CommandEventArgs) (*CommandResult, error) {
+	if len(e.Args) <= 0 {
+		return messageResult(e.optionsString())
+	}
+	for _, arg := range e.Args {
+		if err := e.setOption(arg); err != nil {
+			return nil, err
+		}
+	}
+	e.clearCache()
+	return messageResult("")
+}
+
+// cmdExSort sorts rows by keys like `n`, `price:N` or `$3:s`.
+// The column is the current one when it is omitted.
+func cmdExSort(e *CommandEventArgs) (*CommandResult, error) {
+	if len(e.Args) <= 0 {
+		return messageResult(e.cmdSort([]sortKey{{col: e.cursorCol}}))
+	}
+	keys := make([]sortKey, 0, len(e.Args))
+	for _, arg := range e.Args {
+		col := e.cursorCol
+		typ := arg
+		if i := strings.LastIndexByte(arg, ':'); i >= 0 {
+			var err error
+			col, err = e.columnByName(arg[:i])
+			if err != nil {
+				return nil, err
+			}
+			typ = arg[i+1:]
+		}
+		key, ok := sortTypeKeys[typ]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown sort type", typ)
+		}
+		key.col = col
+		keys = append(keys, key)
+	}
+	return messageResult(e.cmdSort(keys))
+}
+
+func cmdExFilter(e *CommandEventArgs) (*CommandResult, error) {
+	return messageResult(e.applyFilter(strings.Join(e.Args, " ")))
+}
+
+func cmdExUndo(e *CommandEventArgs) (*CommandResult, error) {
+	return messageResult(e.undo())
+}
+
+func cmdExRedo(e *CommandEventArgs) (*CommandResult, error) {
+	return messageResult(e.redo())
+}
+
+// cmdExDiffGet copies the whole current row of the old file in the diff mode.
+func cmdExDiffGet(e *CommandEventArgs) (*CommandResult, error) {
+	if err := e.diffGet(true); err != nil {
+		return nil, err
+	}
+	return messageResult("")
+}
+
+func cmdExChanges(e *CommandEventArgs) (*CommandResult, error) {
+	return messageResult(e.cmdReviewChanges())
+}
+
+func cmdExProblems(e *CommandEventArgs) (*CommandResult, error) {
+	return messageResult(e.cmdProblems())
+}
+
+// cmdExPatch writes the changes not saved yet as a patch for -apply.
+func cmdExPatch(e *CommandEventArgs) (*CommandResult, error) {
+	if len(e.Args) != 1 {
+		return nil, errors.New("Usage: patch FILE")
+	}
+	message, err := e.writePatchFile(e.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	return messageResult(message)
+}
+
+func (app *Application) lookupCommand(name string) func(*CommandEventArgs) (*CommandResult, error) {
+	if f, ok := app.Commands[name]; ok {
+		return f
+	}
+	return builtinCommands[name]
+}
+
+// commandNames returns the names of all the commands for completion.
+func (app *Application) commandNames() candidate.Candidate {
+	names := make([]string, 0, len(builtinCommands)+len(app.Commands))
+	for name := range builtinCommands {
+		names = append(names, name)
+	}
+	for name := range app.Commands {
+		if _, ok := builtinCommands[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return candidate.Candidate(names)
+}
+
+// ExecCommand runs the command line like `w foo.csv` or `q!`
+// with the commands of Config.Commands and the built-in ones.
+// A line starting with a number like `1200` or `50%` moves the cursor to the row.
+func (app *Application) ExecCommand(line string) (*CommandResult, error) {
+	args := strings.Fields(line)
+	if len(args) <= 0 {
+		return &CommandResult{}, nil
+	}
+	name := args[0]
+	if name[0] >= '0' && name[0] <= '9' {
+		args = append([]string{"goto"}, args...)
+		name = "goto"
+	}
+	e := &CommandEventArgs{
+		Application: app,
+		Name:        strings.TrimSuffix(name, "!"),
+		Bang:        strings.HasSuffix(name, "!"),
+		Args:        args[1:],
+	}
+	f := app.lookupCommand(e.Name)
+	if f == nil {
+		return nil, fmt.Errorf("%s: not a command", e.Name)
+	}
+	return f(e)
+}
+
+// cmdColon runs the line. When it is empty, the line is read from the prompt.
+func (app *Application) cmdColon(line string) (*CommandResult, error) {
+	if line == "" {
+		var err error
+		line, err = app.Pilot.ReadLine(app.out, ":", "", app.commandNames())
+		if err != nil {
+			return &CommandResult{}, nil
+		}
+	}
+	return app.ExecCommand(line)
+}
@@ -0,0 +1,46 @@
+package csvi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExecCommand(t *testing.T) {
+	var got *CommandEventArgs
+	app := &Application{
+		Config: &Config{
+			Commands: map[string]func(*CommandEventArgs) (*CommandResult, error){
+				"goto": func(e *CommandEventArgs) (*CommandResult, error) {
+					got = e
+					return &CommandResult{Message: "goto"}, nil
+				},
+				"hello": func(e *CommandEventArgs) (*CommandResult, error) {
+					got = e
+					return &CommandResult{Message: "hello " + strings.Join(e.Args, ",")}, nil
+				},
+			},
+		},
+	}
+	rc, err := app.ExecCommand("hello! foo  bar")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if rc.Message != "hello foo,bar" || !got.Bang || got.Name != "hello" {
+		t.Fatalf("message=%q bang=%v name=%q", rc.Message, got.Bang, got.Name)
+	}
+	// a user command takes precedence over the built-in one.
+	rc, err = app.ExecCommand("12 3")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if rc.Message != "goto" || strings.Join(got.Args, ",") != "12,3" {
+		t.Fatalf("message=%q args=%v", rc.Message, got.Args)
+	}
+	if _, err := app.ExecCommand("nosuchcommand"); err == nil {
+		t.Fatal("an unknown command must fail")
+	}
+	names := app.commandNames()
+	if names.Len() != len(builtinCommands)+1 {
+		t.Fatalf("commandNames: %d names", names.Len())
+	}
+}
@@ -0,0 +1,60 @@
+package csvi_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExGoto(t *testing.T) {
+	src := "a,b,c\nd,e,f\ng,h,i"
+	testCase(t, src, ":|goto 2 3|r|X", "a,b,c\nd,e,X\ng,h,i")
+	testCase(t, src, ":|3|r|X", "a,b,c\nd,e,f\nX,h,i")
+	testCase(t, src, ":|goto 9 9|r|X", "a,b,c\nd,e,f\ng,h,X")
+	// a row number is not taken for the column of the same name
+	testCase(t, "1,2,3\na,b,c\nd,e,f", ":|goto 2|r|X", "1,2,3\nX,b,c\nd,e,f")
+	testCase(t, "1,2,3\na,b,c\nd,e,f", ":|goto 2 3|r|X", "1,2,3\na,b,X\nd,e,f")
+}
+
+func TestExGotoBeyondScreen(t *testing.T) {
+	// the row beyond the end is the last row even when the rows are not read yet
+	rows := []string{"n\n"}
+	for i := 1; i <= 1000; i++ {
+		rows = append(rows, fmt.Sprintf("%04d\n", i))
+	}
+	src := strings.Join(rows, "")
+	testCase(t, src, ":|99999|r|Y", strings.Join(rows[:1000], "")+"Y\n")
+	testCase(t, src, ":|goto 100%|r|Y", strings.Join(rows[:1000], "")+"Y\n")
+}
+
+func TestExSort(t *testing.T) {
+	src := "name,size\nbanana,10\napple,9\ncherry,100"
+	testCase(t, src, ":|sort $2:n", "name,size\napple,9\nbanana,10\ncherry,100")
+	testCase(t, src, ":|sort size:N", "name,size\ncherry,100\nbanana,10\napple,9")
+	testCase(t, src, ":|sort", "name,size\napple,9\nbanana,10\ncherry,100")
+}
+
+func TestExSet(t *testing.T) {
+	src := "a,b\nc,d"
+	testCase(t, src, ":|set readonly|r|X", src)
+	testCase(t, src, ":|set readonly|:|set noreadonly|r|X", "X,b\nc,d")
+	testCase(t, src, ":|set fixcol|i|X", src)
+	testCase(t, "b\na", ":|set header=0|:|sort", "a\nb")
+	testCase(t, "b\na", ":|set h=1|:|sort", "b\na")
+}
+
+func TestExUndo(t *testing.T) {
+	src := "a,b\nc,d"
+	testCase(t, src, "r|X|:|undo", src)
+	testCase(t, src, "r|X|u|:|redo", "X,b\nc,d")
+}
+
+func TestExGotoTarget(t *testing.T) {
+	src := "id,name,price\n1,apple,100\n2,banana,200\n3,cherry,300\n4,melon,400"
+	testCase(t, src, ":|goto 3,price|r|X", "id,name,price\n1,apple,100\n2,banana,X\n3,cherry,300\n4,melon,400")
+	testCase(t, src, ":|goto name|r|X", "id,X,price\n1,apple,100\n2,banana,200\n3,cherry,300\n4,melon,400")
+	testCase(t, src, ":|50%|r|X", "id,name,price\n1,apple,100\nX,banana,200\n3,cherry,300\n4,melon,400")
+	testCase(t, src, ":|100%|r|X", "id,name,price\n1,apple,100\n2,banana,200\n3,cherry,300\nX,melon,400")
+	testCase(t, src, "J|4,$2|r|X", "id,name,price\n1,apple,100\n2,banana,200\n3,X,300\n4,melon,400")
+	testCase(t, src, "j|J|price|r|X", "id,name,price\n1,apple,X\n2,banana,200\n3,cherry,300\n4,melon,400")
+}
@@ -0,0 +1,352 @@
+package csvi
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// rowFilter hides the rows which do not match the expression.
+// The hidden rows are still in csvLines and are saved as usual.
+type rowFilter struct {
+	expr    string
+	match   func(*uncsv.Row) bool
+	valid   bool
+	checked int
+	matched int
+	total   int
+}
+
+type filterCondition struct {
+	col  int // -1 means any column
+	test func(string) bool
+}
+
+func (cond filterCondition) match(row *uncsv.Row) bool {
+	if cond.col >= 0 {
+		if cond.col >= len(row.Cell) {
+			return cond.test("")
+		}
+		return cond.test(row.Cell[cond.col].Text())
+	}
+	for _, c := range row.Cell {
+		if cond.test(c.Text()) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareAsNumberOrString(left, right string) int {
+	l, err1 := strconv.ParseFloat(strings.TrimSpace(left), 64)
+	r, err2 := strconv.ParseFloat(strings.TrimSpace(right), 64)
+	if err1 != nil || err2 != nil {
+		return strings.Compare(left, right)
+	}
+	if l < r {
+		return -1
+	} else if l > r {
+		return 1
+	}
+	return 0
+}
+
+// filterOperators are sorted so that the longer one is tried first.
+var filterOperators = []string{"==", "!=", "<=", ">=", "<", ">", "~", ":"}
+
+func (app *Application) columnByName(name string) (int, error) {
+	if strings.HasPrefix(name, "$") {
+		n, err := strconv.Atoi(name[1:])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("%s: invalid column number", name)
+		}
+		return n - 1, nil
+	}
+	if app.HeaderLines > 0 {
+		for i, c := range app.Front().Cell {
+			if strings.TrimSpace(c.Text()) == name {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%s: no such column", name)
+}
+
+func (app *Application) parseFilterCondition(s string) (filterCondition, error) {
+	at := -1
+	op := ""
+	for _, o := range filterOperators {
+		if i := strings.Index(s, o); i >= 0 && (at < 0 || i < at) {
+			at = i
+			op = o
+		}
+	}
+	if at < 0 {
+		value := s
+		return filterCondition{col: -1, test: func(text string) bool {
+			return strings.Contains(text, value)
+		}}, nil
+	}
+	name := strings.TrimSpace(s[:at])
+	value := strings.TrimSpace(s[at+len(op):])
+	cond := filterCondition{col: -1}
+	if name != "" {
+		var err error
+		cond.col, err = app.columnByName(name)
+		if err != nil {
+			return cond, err
+		}
+	}
+	switch op {
+	case ":":
+		cond.test = func(text string) bool { return strings.Contains(text, value) }
+	case "~":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return cond, err
+		}
+		cond.test = re.MatchString
+	case "==":
+		cond.test = func(text string) bool { return compareAsNumberOrString(text, value) == 0 }
+	case "!=":
+		cond.test = func(text string) bool { return compareAsNumberOrString(text, value) != 0 }
+	case "<":
+		cond.test = func(text string) bool { return compareAsNumberOrString(text, value) < 0 }
+	case "<=":
+		cond.test = func(text string) bool { return compareAsNumberOrString(text, value) <= 0 }
+	case ">":
+		cond.test = func(text string) bool { return compareAsNumberOrString(text, value) > 0 }
+	case ">=":
+		cond.test = func(text string) bool { return compareAsNumberOrString(text, value) >= 0 }
+	}
+	return cond, nil
+}
+
+// newRowFilter compiles an expression like `$2>=100 && name~^A || memo:TODO`.
+// "&&" binds more tightly than "||".
+func (app *Application) newRowFilter(expr string) (*rowFilter, error) {
+	var groups [][]filterCondition
+	for _, orTerm := range strings.Split(expr, "||") {
+		var group []filterCondition
+		for _, andTerm := range strings.Split(orTerm, "&&") {
+			andTerm = strings.TrimSpace(andTerm)
+			if andTerm == "" {
+				return nil, errors.New("empty condition")
+			}
+			cond, err := app.parseFilterCondition(andTerm)
+			if err != nil {
+				return nil, err
+			}
+			group = append(group, cond)
+		}
+		groups = append(groups, group)
+	}
+	return &rowFilter{
+		expr: expr,
+		match: func(row *uncsv.Row) bool {
+			for _, group := range groups {
+				ok := true
+				for _, cond := range group {
+					if !cond.match(row) {
+						ok = false
+						break
+					}
+				}
+				if ok {
+					return true
+				}
+			}
+			return false
+		},
+	}, nil
+}
+
+// isVisible returns false when the row is hidden by the filter.
+// Header lines and the row under the cursor are always visible.
+func (app *Application) isVisible(p *RowPtr) bool {
+	if app.filter == nil || p.Index() < app.HeaderLines {
+		return true
+	}
+	if app.cursorRow != nil && p.element == app.cursorRow.element {
+		return true
+	}
+	return app.filter.match(p.Row)
+}
+
+// isVisibleAt is isVisible for the row at lnum read by visitRows.
+func (app *Application) isVisibleAt(lnum int, row *uncsv.Row) bool {
+	if app.filter == nil || lnum < app.HeaderLines {
+		return true
+	}
+	if app.cursorRow != nil && lnum == app.cursorRow.Index() {
+		return true
+	}
+	return app.filter.match(row)
+}
+
+// visibleFrom returns the line number of the first row visible from lnum
+// to the end, or to the top when backward is true, or -1 when there is none.
+// The rows hidden are not taken out of the runs of Config.Lazy.
+func (app *Application) visibleFrom(lnum int, backward bool) int {
+	if app.filter == nil {
+		if lnum < 0 || lnum >= app.Len() {
+			return -1
+		}
+		return lnum
+	}
+	found := -1
+	visit := app.visitRows
+	if backward {
+		visit = app.visitRowsBackward
+	}
+	visit(lnum, func(lnum int, row *uncsv.Row) bool {
+		if app.isVisibleAt(lnum, row) {
+			found = lnum
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func (app *Application) nextVisible(p *RowPtr) *RowPtr {
+	if app.filter == nil {
+		return p.Next()
+	}
+	if lnum := app.visibleFrom(p.Index()+1, false); lnum >= 0 {
+		return app.seek(lnum)
+	}
+	return nil
+}
+
+func (app *Application) prevVisible(p *RowPtr) *RowPtr {
+	if app.filter == nil {
+		return p.Prev()
+	}
+	if lnum := app.visibleFrom(p.Index()-1, true); lnum >= 0 {
+		return app.seek(lnum)
+	}
+	return nil
+}
+
+func (app *Application) frontVisible() *RowPtr {
+	p := app.Front()
+	if app.isVisible(p) {
+		return p
+	}
+	if next := app.nextVisible(p); next != nil {
+		return next
+	}
+	return p
+}
+
+func (app *Application) backVisible() *RowPtr {
+	p := app.Back()
+	if app.isVisible(p) {
+		return p
+	}
+	if prev := app.prevVisible(p); prev != nil {
+		return prev
+	}
+	return p
+}
+
+// visibleDistance returns the number of visible rows from `from` to `to`.
+// It returns -1 when `to` is before `from`, and stops counting at limit.
+func (app *Application) visibleDistance(from, to *RowPtr, limit int) int {
+	if app.filter == nil {
+		return to.Index() - from.Index()
+	}
+	if to.Index() < from.Index() {
+		return -1
+	}
+	n := 0
+	for p := from; p != nil && p.element != to.element && n < limit; p = app.nextVisible(p) {
+		n++
+	}
+	return n
+}
+
+func (app *Application) invalidateFilterCount() {
+	if app.filter != nil {
+		app.filter.valid = false
+	}
+}
+
+// filterCount returns the number of the rows matching the filter
+// and the number of all the rows except the header lines.
+// Only the rows loaded after the last call are counted
+// unless the rows are edited after it.
+func (app *Application) filterCount() (matched, total int) {
+	f := app.filter
+	if !f.valid || f.checked > app.Len() {
+		f.matched = 0
+		f.total = 0
+		f.checked = app.HeaderLines
+		f.valid = true
+	}
+	if f.checked < app.Len() {
+		app.visitRows(f.checked, func(_ int, row *uncsv.Row) bool {
+			if f.match(row) {
+				f.matched++
+			}
+			f.total++
+			return true
+		})
+		f.checked = app.Len()
+	}
+	return f.matched, f.total
+}
+
+func (app *Application) cmdFilter() string {
+	defaultText := ""
+	if app.filter != nil {
+		defaultText = app.filter.expr
+	}
+	expr, err := app.Pilot.ReadLine(app.out, "filter>", defaultText, nil)
+	if err != nil {
+		return ""
+	}
+	return app.applyFilter(expr)
+}
+
+// applyFilter shows only the rows matching expr. An empty expr clears the filter.
+func (app *Application) applyFilter(expr string) string {
+	if strings.TrimSpace(expr) == "" {
+		app.filter = nil
+		app.startRow = app.cursorRow.Clone()
+		app.clearCache()
+		return "Filter cleared"
+	}
+	f, err := app.newRowFilter(expr)
+	if err != nil {
+		return err.Error()
+	}
+	ctx, cancel := app.withSlowOperation("Reading all data...")
+	app.fetchAll(ctx)
+	ctxErr := ctx.Err()
+	cancel()
+	if ctxErr != nil {
+		return "Filter interrupted"
+	}
+	save := app.filter
+	app.filter = f
+	if matched, _ := app.filterCount(); matched <= 0 {
+		app.filter = save
+		return fmt.Sprintf("%s: not found", expr)
+	}
+	if app.cursorRow.Index() >= app.HeaderLines && !f.match(app.cursorRow.Row) {
+		if next := app.nextVisible(app.cursorRow); next != nil {
+			app.cursorRow = next
+		} else if prev := app.prevVisible(app.cursorRow); prev != nil {
+			app.cursorRow = prev
+		}
+	}
+	app.startRow = app.cursorRow.Clone()
+	app.clearCache()
+	return ""
+}
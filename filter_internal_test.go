@@ -0,0 +1,45 @@
+package csvi
+
+import (
+	"testing"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+func TestFilterCount(t *testing.T) {
+	mode := &uncsv.Mode{Comma: ','}
+	app := (&Config{Mode: mode}).newApplication(nil)
+	load := func(texts ...string) {
+		for _, s := range texts {
+			row := uncsv.NewRowFromStringSlice(mode, []string{s})
+			app.load(&row)
+		}
+	}
+	load("a", "b", "a")
+	app.cursorRow = app.Front()
+	app.startRow = app.Front()
+	f, err := app.newRowFilter("a")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	app.filter = f
+	if m, n := app.filterCount(); m != 2 || n != 3 {
+		t.Fatalf("expect 2/3, but %d/%d", m, n)
+	}
+
+	// the rows loaded later are counted without the rows counted already
+	f.matched += 100
+	load("c", "a")
+	if m, n := app.filterCount(); m != 103 || n != 5 {
+		t.Fatalf("expect 103/5 counted incrementally, but %d/%d", m, n)
+	}
+
+	// all the rows are counted again after an edit
+	row := app.Front().Row
+	before := takeRowImage(row)
+	app.setTexts(row, []string{"x"})
+	app.recordRowChange(row, before)
+	if m, n := app.filterCount(); m != 2 || n != 5 {
+		t.Fatalf("expect 2/5 after the edit, but %d/%d", m, n)
+	}
+}
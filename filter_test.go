@@ -0,0 +1,27 @@
+package csvi_test
+
+import (
+	"testing"
+)
+
+func TestFilterDeleteRow(t *testing.T) {
+	src := "name,n\na,1\nb,2\nc,3\nd,4"
+	testCase(t, src, "j|F|n>=3|d|d", "name,n\na,1\nb,2\nd,4")
+	testCase(t, src, "j|F|$2<3 && name:b|d|d", "name,n\na,1\nc,3\nd,4")
+}
+
+func TestFilterCursorMove(t *testing.T) {
+	src := "name,n\na,1\nb,2\nc,3\nd,4"
+	testCase(t, src, "F|name~^[bd]|j|j|r|X", "name,n\na,1\nb,2\nc,3\nX,4")
+	testCase(t, src, "F|name~^[bd]|>|k|r|X", "name,n\na,1\nX,2\nc,3\nd,4")
+	testCase(t, src, "F|name~^[bd]|F||j|r|X", "name,n\nX,1\nb,2\nc,3\nd,4")
+	testCase(t, src, "F|zzz|j|r|X", "name,n\nX,1\nb,2\nc,3\nd,4")
+}
+
+func TestFilterSearch(t *testing.T) {
+	src := "k,v\nx,1\nt,2\nt,1"
+	testCase(t, src, "F|v==1|/|t|r|Q", "k,v\nx,1\nt,2\nQ,1")
+	// the search goes on after the match in the hidden row
+	src = "a,b\nx,1\ny,zz\nz,zz"
+	testCase(t, src, "F|a~^[xz]|/|zz|r|Q", "a,b\nx,1\ny,zz\nz,Q")
+}
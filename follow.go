@@ -0,0 +1,119 @@
+package csvi
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+const (
+	// followPollInterval is the interval to check whether the file has grown.
+	followPollInterval = 500 * time.Millisecond
+
+	// followIdleTime is the time without rows after which errFollowIdle is returned
+	followIdleTime = 250 * time.Millisecond
+)
+
+// errFollowIdle is returned by the fetch function of Config.Follow
+// when all the rows written so far are read.
+var errFollowIdle = errors.New("waiting for rows appended")
+
+// followReader reads the data appended after EOF like `tail -f`
+// until it is stopped.
+type followReader struct {
+	r    io.Reader
+	stop <-chan struct{}
+	// passEOF makes Read return io.EOF instead of waiting for data
+	passEOF bool
+}
+
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		if n > 0 {
+			return n, nil
+		}
+		if !errors.Is(err, io.EOF) || f.passEOF {
+			return n, err
+		}
+		if !f.wait() {
+			return 0, io.EOF
+		}
+	}
+}
+
+// wait waits for a while and returns false when it is stopped.
+func (f *followReader) wait() bool {
+	select {
+	case <-f.stop:
+		return false
+	case <-time.After(followPollInterval):
+		return true
+	}
+}
+
+// detectBom sets the encoding by the head of the data
+// without waiting for all the bytes ReadLine peeks.
+func (f *followReader) detectBom(br *bufio.Reader, mode *uncsv.Mode) bool {
+	f.passEOF = true
+	defer func() { f.passEOF = false }()
+	for {
+		if _, err := br.Peek(1); err == nil {
+			break
+		} else if !errors.Is(err, io.EOF) || !f.wait() {
+			return false
+		}
+	}
+	mode.DetectBom(br)
+	return true
+}
+
+type fetchResult struct {
+	row *uncsv.Row
+	err error
+}
+
+// followFetch returns the function which reads rows from r appended after EOF
+// and the function to stop it. A row still being written is not returned
+// until its end is written. The returned function returns errFollowIdle
+// when no row comes for a while so that the screen can be updated.
+func followFetch(r io.Reader, mode *uncsv.Mode) (func() (*uncsv.Row, error), func()) {
+	stop := make(chan struct{})
+	fr := &followReader{r: r, stop: stop}
+	br := bufio.NewReader(fr)
+	ch := make(chan fetchResult)
+	go func() {
+		// this goroutine may be left blocking on a pipe like STDIN
+		// until the writer writes or closes it.
+		if !mode.HasBom() && !fr.detectBom(br, mode) {
+			return
+		}
+		for {
+			row, err := uncsv.ReadLine(br, mode)
+			select {
+			case ch <- fetchResult{row: row, err: err}:
+			case <-stop:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	fetch := func() (*uncsv.Row, error) {
+		select {
+		case res := <-ch:
+			return res.row, res.err
+		case <-stop:
+			return nil, io.EOF
+		case <-time.After(followIdleTime):
+			return nil, errFollowIdle
+		}
+	}
+	return fetch, func() { once.Do(func() { close(stop) }) }
+}
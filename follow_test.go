@@ -0,0 +1,93 @@
+package csvi_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hymkor/csvi"
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// drainReader counts the bytes read and remembers whether the last Read
+// reached EOF, so that the test can know the follower is waiting for data.
+type drainReader struct {
+	r       io.Reader
+	n       atomic.Int64
+	drained atomic.Bool
+}
+
+func (d *drainReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	d.n.Add(int64(n))
+	d.drained.Store(n == 0 && errors.Is(err, io.EOF))
+	return n, err
+}
+
+// testFollow follows the file while `:append TEXT` appends TEXT to it
+// and `:check` records the number of rows and the cursor row.
+// "..." waits until all the text is read and the rows ended are loaded.
+func testFollow(t *testing.T, source, script string) []string {
+	t.Helper()
+	path := makeSource(t, "log.csv", source)
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer fd.Close()
+	src := &drainReader{r: fd}
+	written := source
+	var checks []string
+	var ready atomic.Bool
+	cfg := &csvi.Config{
+		Mode:   &uncsv.Mode{Comma: ','},
+		Pilot:  &scriptPilot{script: strings.Split(script, "|"), ready: &ready},
+		Follow: true,
+		Commands: map[string]func(*csvi.CommandEventArgs) (*csvi.CommandResult, error){
+			"append": func(e *csvi.CommandEventArgs) (*csvi.CommandResult, error) {
+				text := strings.ReplaceAll(strings.Join(e.Args, " "), `\n`, "\n")
+				w, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0666)
+				if err != nil {
+					return nil, err
+				}
+				_, err = io.WriteString(w, text)
+				w.Close()
+				written += text
+				return &csvi.CommandResult{}, err
+			},
+			"sync": func(e *csvi.CommandEventArgs) (*csvi.CommandResult, error) {
+				ready.Store(src.drained.Load() &&
+					src.n.Load() == int64(len(written)) &&
+					e.Len() == strings.Count(written, "\n"))
+				return &csvi.CommandResult{}, nil
+			},
+			"check": func(e *csvi.CommandEventArgs) (*csvi.CommandResult, error) {
+				checks = append(checks, fmt.Sprintf("%d/%d %s",
+					e.CurrentRow().Index()+1, e.Len(), e.CurrentRow().Cell[0].Text()))
+				return &csvi.CommandResult{}, nil
+			},
+		},
+	}
+	if _, err := cfg.Edit(src, io.Discard); err != nil {
+		t.Fatal(err.Error())
+	}
+	return checks
+}
+
+func TestFollow(t *testing.T) {
+	checks := testFollow(t, "a\nb\n",
+		// the cursor at the top stays, and the one on the last row follows
+		`:|append c\n|...|:|check|G|:|append d\n|...|:|check|`+
+			// the row not terminated yet is not read
+			`:|append e|...|:|check|:|append ,f\n|...|:|check|`+
+			// rows are read-only
+			`r|X|:|check|q`)
+	expect := []string{"1/3 a", "4/4 d", "4/4 d", "5/5 e", "5/5 e"}
+	if strings.Join(checks, "|") != strings.Join(expect, "|") {
+		t.Fatalf("expect %q, but %q", expect, checks)
+	}
+}
@@ -0,0 +1,60 @@
+package csvi
+
+import (
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// scrolledCol returns the first column drawn after the frozen columns.
+func (app *Application) scrolledCol() int {
+	if app.startCol < app.frozenCols {
+		return app.frozenCols
+	}
+	return app.startCol
+}
+
+// columnAt returns the index of the column drawn at the n-th position of the screen.
+func (app *Application) columnAt(n int) int {
+	if n < app.frozenCols {
+		return n
+	}
+	return n - app.frozenCols + app.scrolledCol()
+}
+
+func (app *Application) cursorOnScreen() int {
+	if app.cursorCol < app.frozenCols {
+		return app.cursorCol
+	}
+	return app.cursorCol - app.scrolledCol() + app.frozenCols
+}
+
+func (app *Application) cellsOnScreen(cells []uncsv.Cell) []uncsv.Cell {
+	if app.frozenCols <= 0 {
+		return cellsAfter(cells, app.startCol)
+	}
+	if len(cells) <= app.frozenCols {
+		return cells
+	}
+	rest := cellsAfter(cells, app.scrolledCol())
+	result := make([]uncsv.Cell, 0, app.frozenCols+len(rest))
+	result = append(result, cells[:app.frozenCols]...)
+	return append(result, rest...)
+}
+
+// toggleFreeze freezes the columns set by -freezecol or `:set freezecol`,
+// or the columns up to the cursor when none is set. It unfreezes them
+// when they are frozen.
+func (app *Application) toggleFreeze() string {
+	app.clearCache()
+	if app.frozenCols > 0 {
+		app.frozenCols = 0
+		return "Columns unfrozen"
+	}
+	app.frozenCols = app.FreezeColumns
+	if app.frozenCols <= 0 {
+		app.frozenCols = app.cursorCol + 1
+	}
+	if app.startCol < app.frozenCols {
+		app.startCol = app.frozenCols
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package csvi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+func TestCellsOnScreen(t *testing.T) {
+	mode := &uncsv.Mode{Comma: ','}
+	row := uncsv.NewRowFromStringSlice(mode, strings.Split("a,b,c,d,e,f", ","))
+	app := &Application{frozenCols: 2, startCol: 4, cursorCol: 5}
+
+	var texts []string
+	for _, c := range app.cellsOnScreen(row.Cell) {
+		texts = append(texts, c.Text())
+	}
+	if s := strings.Join(texts, ","); s != "a,b,e,f" {
+		t.Fatalf("cellsOnScreen: %s", s)
+	}
+	if n := app.columnAt(2); n != 4 {
+		t.Fatalf("columnAt(2) = %d", n)
+	}
+	if n := app.columnAt(1); n != 1 {
+		t.Fatalf("columnAt(1) = %d", n)
+	}
+	if n := app.cursorOnScreen(); n != 3 {
+		t.Fatalf("cursorOnScreen() = %d", n)
+	}
+	app.startCol = 0 // not scrolled yet
+	if n := app.columnAt(2); n != 2 {
+		t.Fatalf("columnAt(2) = %d when startCol is 0", n)
+	}
+}
+
+func TestDrawFrozenSeparator(t *testing.T) {
+	mode := &uncsv.Mode{Comma: ','}
+	row := uncsv.NewRowFromStringSlice(mode, []string{"id", "", "name"})
+	style := lineStyle{
+		cellWidth:   func(int) int { return 6 },
+		screenWidth: 80,
+		colorStyle:  &monoChromeStyle,
+		frozen:      1,
+	}
+	var buffer strings.Builder
+	style.drawLine(row.Cell, -1, false, &buffer)
+	if result := buffer.String(); !strings.Contains(result, frozenSep) {
+		t.Fatalf("%q does not contain %q", result, frozenSep)
+	}
+}
+
+func TestToggleFreeze(t *testing.T) {
+	app := &Application{Config: &Config{FreezeColumns: 2}, cursorCol: 5}
+	app.frozenCols = app.FreezeColumns
+
+	app.toggleFreeze()
+	if app.frozenCols != 0 {
+		t.Fatalf("frozenCols = %d after unfreezing", app.frozenCols)
+	}
+	// the columns of -freezecol are frozen again, not the ones up to the cursor
+	app.toggleFreeze()
+	if app.frozenCols != 2 {
+		t.Fatalf("frozenCols = %d, expect 2", app.frozenCols)
+	}
+	if n := app.columnAt(1); n != 1 {
+		t.Fatalf("columnAt(1) = %d", n)
+	}
+	if n := app.columnAt(2); n != 2 {
+		t.Fatalf("columnAt(2) = %d", n)
+	}
+	if n := app.cursorOnScreen(); n != 5 {
+		t.Fatalf("cursorOnScreen() = %d", n)
+	}
+
+	// without -freezecol, the columns up to the cursor are frozen
+	app = &Application{Config: &Config{}, cursorCol: 3}
+	app.toggleFreeze()
+	if app.frozenCols != 4 {
+		t.Fatalf("frozenCols = %d, expect 4", app.frozenCols)
+	}
+	if n := app.cursorOnScreen(); n != 3 {
+		t.Fatalf("cursorOnScreen() = %d", n)
+	}
+	if n := app.columnAt(4); n != 4 {
+		t.Fatalf("columnAt(4) = %d", n)
+	}
+}
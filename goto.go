@@ -0,0 +1,152 @@
+package csvi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hymkor/csvi/candidate"
+)
+
+// gotoCell moves the cursor to the cell fetching rows as needed,
+// and puts the row at the middle of the screen when it is not on the screen.
+// A negative lnum or col keeps the current row or column.
+func (app *Application) gotoCell(lnum, col int) {
+	if lnum >= app.Len() {
+		ctx, cancel := app.withSlowOperation("Reading data...")
+		app.fetchUntil(ctx, lnum)
+		cancel()
+	}
+	last := app.Len() - 1
+	if last > 0 && app.Back().IsZero() {
+		// the empty row at EOF is not a row to edit
+		last--
+	}
+	if lnum > last {
+		lnum = last
+	}
+	if lnum >= 0 && lnum != app.cursorRow.Index() {
+		p := app.seek(lnum)
+		if !app.isVisible(p) {
+			if next := app.nextVisible(p); next != nil {
+				p = next
+			} else if prev := app.prevVisible(p); prev != nil {
+				p = prev
+			}
+		}
+		onScreen := app.startRow.Index() <= p.Index() &&
+			app.visibleDistance(app.startRow, p, app.screenHeight-1) < app.screenHeight-1
+		app.cursorRow = p
+		if !onScreen {
+			app.startRow = p.Clone()
+			for i := 0; i < app.screenHeight/2; i++ {
+				prev := app.prevVisible(app.startRow)
+				if prev == nil {
+					break
+				}
+				app.startRow = prev
+			}
+		}
+	}
+	if col >= 0 {
+		app.cursorCol = col
+	}
+	if L := len(app.cursorRow.Cell); app.cursorCol >= L {
+		app.cursorCol = L - 1
+	}
+}
+
+// parseGotoRow returns the line number for `ROW` counted from 1 or `N%`.
+func (app *Application) parseGotoRow(s string) (int, error) {
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		n, err := strconv.ParseFloat(pct, 64)
+		if err != nil || n < 0 || n > 100 {
+			return 0, fmt.Errorf("%s: invalid percentage", s)
+		}
+		ctx, cancel := app.withSlowOperation("Reading all data...")
+		app.fetchAll(ctx)
+		cancel()
+		return int(float64(app.Len()-1) * n / 100), nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s: invalid row number", s)
+	}
+	return n - 1, nil
+}
+
+// parseGotoColumn returns the column for a number counted from 1,
+// `$N` or the name in the header.
+func (app *Application) parseGotoColumn(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n <= 0 {
+			return 0, fmt.Errorf("%s: invalid column number", s)
+		}
+		return n - 1, nil
+	}
+	return app.columnByName(s)
+}
+
+// parseGotoCell returns the row and the column for `ROW`, `ROW,COL` or `ROW COL`.
+// The column is -1 when it is omitted.
+func (app *Application) parseGotoCell(target string) (int, int, error) {
+	rowPart, colPart, hasCol := strings.Cut(target, ",")
+	if !hasCol {
+		rowPart, colPart, hasCol = strings.Cut(target, " ")
+	}
+	col := -1
+	if hasCol {
+		var err error
+		col, err = app.parseGotoColumn(strings.TrimSpace(colPart))
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	lnum, err := app.parseGotoRow(strings.TrimSpace(rowPart))
+	if err != nil {
+		return 0, 0, err
+	}
+	return lnum, col, nil
+}
+
+// gotoTarget moves the cursor to the target like `ROW`, `ROW,COL`, `ROW COL`,
+// `N%` or a column name. A row number is preferred to the column of the same name.
+func (app *Application) gotoTarget(target string) error {
+	target = strings.TrimSpace(target)
+	app.rememberJump()
+	lnum, col, err := app.parseGotoCell(target)
+	if err != nil {
+		if col, err := app.columnByName(target); err == nil {
+			app.gotoCell(-1, col)
+			return nil
+		}
+		return err
+	}
+	app.gotoCell(lnum, col)
+	return nil
+}
+
+// headerNames returns the names in the header for completion.
+func (app *Application) headerNames() candidate.Candidate {
+	if app.HeaderLines <= 0 {
+		return nil
+	}
+	var names []string
+	for _, c := range app.Front().Cell {
+		if name := strings.TrimSpace(c.Text()); name != "" {
+			names = append(names, name)
+		}
+	}
+	return candidate.Candidate(names)
+}
+
+func (app *Application) cmdGoto() string {
+	target, err := app.Pilot.ReadLine(app.out, "goto (ROW, ROW,COL, N% or column name)>", "", app.headerNames())
+	if err != nil || strings.TrimSpace(target) == "" {
+		return ""
+	}
+	if err := app.gotoTarget(target); err != nil {
+		return err.Error()
+	}
+	return ""
+}
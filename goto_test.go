@@ -0,0 +1,52 @@
+package csvi
+
+import (
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+func TestGotoCellScroll(t *testing.T) {
+	cfg := &Config{Mode: &uncsv.Mode{Comma: ','}}
+	app := cfg.newApplication(io.Discard)
+	for i := 0; i < 100; i++ {
+		row := uncsv.NewRowFromStringSlice(cfg.Mode, []string{strconv.Itoa(i)})
+		app.push(&row)
+	}
+	app.startRow = app.Front()
+	app.cursorRow = app.Front()
+	app.screenHeight = 10
+
+	app.gotoCell(50, -1)
+	if app.cursorRow.Index() != 50 || app.startRow.Index() != 45 {
+		t.Fatalf("cursor=%d start=%d", app.cursorRow.Index(), app.startRow.Index())
+	}
+	app.gotoCell(47, -1)
+	if app.cursorRow.Index() != 47 || app.startRow.Index() != 45 {
+		t.Fatalf("cursor=%d start=%d (on the screen)", app.cursorRow.Index(), app.startRow.Index())
+	}
+	app.gotoCell(500, 3)
+	if app.cursorRow.Index() != 99 || app.cursorCol != 0 {
+		t.Fatalf("cursor=%d col=%d (beyond the end)", app.cursorRow.Index(), app.cursorCol)
+	}
+}
+
+func TestGotoCellLastRow(t *testing.T) {
+	cfg := &Config{Mode: &uncsv.Mode{Comma: ','}}
+	app := cfg.newApplication(io.Discard)
+	for i := 0; i < 3; i++ {
+		row := uncsv.NewRowFromStringSlice(cfg.Mode, []string{strconv.Itoa(i)})
+		app.push(&row)
+	}
+	app.push(&uncsv.Row{})
+	app.startRow = app.Front()
+	app.cursorRow = app.Front()
+	app.screenHeight = 10
+
+	app.gotoCell(99999, -1)
+	if app.cursorRow.Index() != 2 {
+		t.Fatalf("cursor=%d (the empty row at EOF)", app.cursorRow.Index())
+	}
+}
@@ -8,10 +8,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/mattn/go-colorable"
 
+	"github.com/hymkor/csvi/candidate"
 	"github.com/hymkor/csvi/csviapp"
 )
 
@@ -64,7 +67,7 @@ func testCase(t *testing.T, source, process, result string, options ...string) {
 	checkResult(t, path, result)
 }
 
-func makeSource(t *testing.T, name, content string) string {
+func makeSource(t testing.TB, name, content string) string {
 	t.Helper()
 	dir := t.TempDir()
 	path := filepath.Join(dir, name)
@@ -75,7 +78,7 @@ func makeSource(t *testing.T, name, content string) string {
 	return path
 }
 
-func disableStdout(t *testing.T) func() {
+func disableStdout(t testing.TB) func() {
 	if testing.Verbose() {
 		return func() {}
 	}
@@ -90,3 +93,50 @@ func disableStdout(t *testing.T) func() {
 		os.Stdout = stdoutSave
 	}
 }
+
+// scriptPilot gives the keys and the lines separated by "|" like -auto
+// to test with csvi.Config directly. "..." types `:sync` again and again
+// until the command of the test sets ready, so that the test can wait
+// for the rows read in the background.
+type scriptPilot struct {
+	script []string
+	ready  *atomic.Bool
+	polls  int
+}
+
+func (p *scriptPilot) next() (string, error) {
+	if len(p.script) <= 0 {
+		return "", io.EOF
+	}
+	s := p.script[0]
+	if s != "..." {
+		p.script = p.script[1:]
+		return s, nil
+	}
+	if p.ready.Swap(false) {
+		p.script = p.script[1:]
+		p.polls = 0
+		return p.next()
+	}
+	p.polls++
+	if p.polls > 500 {
+		return "", errors.New("scriptPilot: timeout on \"...\"")
+	}
+	if p.polls > 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	p.script = append([]string{":", "sync"}, p.script...)
+	return p.next()
+}
+
+func (p *scriptPilot) Size() (int, int, error) { return 80, 25, nil }
+func (p *scriptPilot) GetKey() (string, error) { return p.next() }
+func (p *scriptPilot) Close() error            { return nil }
+
+func (p *scriptPilot) ReadLine(io.Writer, string, string, candidate.Candidate) (string, error) {
+	return p.next()
+}
+
+func (p *scriptPilot) GetFilename(io.Writer, string, string) (string, error) {
+	return p.next()
+}
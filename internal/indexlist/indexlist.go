@@ -0,0 +1,365 @@
+// Package indexlist is a doubly linked list like container/list
+// which also finds the element at an index and the index of an element
+// in O(log n). It is a treap whose in-order is the order of the list.
+//
+// An element can take two or more indexes with SetWeight
+// so that it stands for a range of items which are not elements yet.
+package indexlist
+
+// Element is an element of List.
+type Element[T any] struct {
+	Value T
+
+	left, right, parent *Element[T]
+	size                int // the sum of the weights in the subtree
+	weight              int // the number of the indexes of the element (0 is 1)
+	priority            uint32
+	list                *List[T]
+}
+
+// List is the list. The zero value is an empty list ready to use.
+type List[T any] struct {
+	root *Element[T]
+	seed uint32
+	mods int
+}
+
+// New returns an empty list.
+func New[T any]() *List[T] {
+	return new(List[T]).Init()
+}
+
+// Init clears the list.
+func (l *List[T]) Init() *List[T] {
+	l.root = nil
+	l.mods++
+	return l
+}
+
+// Len returns the number of the indexes in O(1),
+// which is the number of the elements when no weights are set.
+func (l *List[T]) Len() int {
+	return size(l.root)
+}
+
+// Weight returns the number of the indexes which the element takes.
+func (e *Element[T]) Weight() int {
+	if e.weight <= 0 {
+		return 1
+	}
+	return e.weight
+}
+
+// SetWeight makes e take w (>= 1) indexes. The indexes of the elements
+// after e are shifted.
+func (l *List[T]) SetWeight(e *Element[T], w int) {
+	if w < 1 {
+		w = 1
+	}
+	if e.Index() < 0 {
+		e.weight = w
+		return
+	}
+	delta := w - e.Weight()
+	e.weight = w
+	for p := e; p != nil; p = p.parent {
+		p.size += delta
+	}
+	l.mods++
+}
+
+// Mods returns the number of times the elements are inserted or removed.
+// The indexes known before are still valid while it is not changed.
+func (l *List[T]) Mods() int {
+	return l.mods
+}
+
+func size[T any](e *Element[T]) int {
+	if e == nil {
+		return 0
+	}
+	return e.size
+}
+
+func (e *Element[T]) update() {
+	e.size = e.Weight() + size(e.left) + size(e.right)
+}
+
+func (e *Element[T]) leftmost() *Element[T] {
+	for e.left != nil {
+		e = e.left
+	}
+	return e
+}
+
+func (e *Element[T]) rightmost() *Element[T] {
+	for e.right != nil {
+		e = e.right
+	}
+	return e
+}
+
+// Front returns the first element or nil.
+func (l *List[T]) Front() *Element[T] {
+	if l.root == nil {
+		return nil
+	}
+	return l.root.leftmost()
+}
+
+// Back returns the last element or nil.
+func (l *List[T]) Back() *Element[T] {
+	if l.root == nil {
+		return nil
+	}
+	return l.root.rightmost()
+}
+
+// At returns the element at index (counted from 0) or nil.
+// For the element with the weight, it is the element which takes index.
+func (l *List[T]) At(index int) *Element[T] {
+	e, _ := l.Locate(index)
+	return e
+}
+
+// Locate returns the element which takes index (counted from 0)
+// and the offset of index from the first index of the element.
+// It returns nil when index is out of range.
+func (l *List[T]) Locate(index int) (*Element[T], int) {
+	if index < 0 || index >= l.Len() {
+		return nil, 0
+	}
+	e := l.root
+	for {
+		n := size(e.left)
+		if index < n {
+			e = e.left
+		} else if w := e.Weight(); index < n+w {
+			return e, index - n
+		} else {
+			index -= n + w
+			e = e.right
+		}
+	}
+}
+
+// Next returns the next element or nil.
+func (e *Element[T]) Next() *Element[T] {
+	if e.list == nil {
+		return nil
+	}
+	if e.right != nil {
+		return e.right.leftmost()
+	}
+	for p := e; p.parent != nil; p = p.parent {
+		if p.parent.left == p {
+			return p.parent
+		}
+	}
+	return nil
+}
+
+// Prev returns the previous element or nil.
+func (e *Element[T]) Prev() *Element[T] {
+	if e.list == nil {
+		return nil
+	}
+	if e.left != nil {
+		return e.left.rightmost()
+	}
+	for p := e; p.parent != nil; p = p.parent {
+		if p.parent.right == p {
+			return p.parent
+		}
+	}
+	return nil
+}
+
+// Index returns the index of the element counted from 0,
+// which is the first index of it with the weight,
+// or -1 when it is no longer in the list.
+func (e *Element[T]) Index() int {
+	if e.list == nil {
+		return -1
+	}
+	index := size(e.left)
+	p := e
+	for ; p.parent != nil; p = p.parent {
+		if p.parent.right == p {
+			index += size(p.parent.left) + p.parent.Weight()
+		}
+	}
+	if p != e.list.root {
+		// the list is cleared by Init
+		return -1
+	}
+	return index
+}
+
+func (l *List[T]) random() uint32 {
+	// xorshift32
+	if l.seed == 0 {
+		l.seed = 2463534242
+	}
+	l.seed ^= l.seed << 13
+	l.seed ^= l.seed >> 17
+	l.seed ^= l.seed << 5
+	return l.seed
+}
+
+// rotateUp moves x to the place of its parent keeping the order.
+func (l *List[T]) rotateUp(x *Element[T]) {
+	p := x.parent
+	g := p.parent
+	if p.left == x {
+		p.left = x.right
+		if x.right != nil {
+			x.right.parent = p
+		}
+		x.right = p
+	} else {
+		p.right = x.left
+		if x.left != nil {
+			x.left.parent = p
+		}
+		x.left = p
+	}
+	p.parent = x
+	x.parent = g
+	if g == nil {
+		l.root = x
+	} else if g.left == p {
+		g.left = x
+	} else {
+		g.right = x
+	}
+	p.update()
+	x.update()
+}
+
+// insert puts e as the left or right child of parent, which has no such child.
+func (l *List[T]) insert(e, parent *Element[T], left bool) *Element[T] {
+	e.list = l
+	e.left, e.right, e.parent = nil, nil, nil
+	e.size = e.Weight()
+	e.priority = l.random()
+	l.mods++
+	if parent == nil {
+		l.root = e
+		return e
+	}
+	e.parent = parent
+	if left {
+		parent.left = e
+	} else {
+		parent.right = e
+	}
+	for p := parent; p != nil; p = p.parent {
+		p.size += e.size
+	}
+	for e.parent != nil && e.parent.priority < e.priority {
+		l.rotateUp(e)
+	}
+	return e
+}
+
+// InsertAfter inserts v after mark and returns the new element.
+// It returns nil when mark is not an element of l.
+func (l *List[T]) InsertAfter(v T, mark *Element[T]) *Element[T] {
+	return l.InsertElementAfter(&Element[T]{Value: v}, mark)
+}
+
+// InsertElementAfter inserts e, which is not in the list like the element removed,
+// after mark and returns e. The references to the element removed get valid again.
+// It returns nil when e is in the list or mark is not an element of l.
+func (l *List[T]) InsertElementAfter(e, mark *Element[T]) *Element[T] {
+	if mark.list != l || e.Index() >= 0 {
+		return nil
+	}
+	if mark.right == nil {
+		return l.insert(e, mark, false)
+	}
+	return l.insert(e, mark.right.leftmost(), true)
+}
+
+// InsertBefore inserts v before mark and returns the new element.
+// It returns nil when mark is not an element of l.
+func (l *List[T]) InsertBefore(v T, mark *Element[T]) *Element[T] {
+	if mark.list != l {
+		return nil
+	}
+	e := &Element[T]{Value: v}
+	if mark.left == nil {
+		return l.insert(e, mark, true)
+	}
+	return l.insert(e, mark.left.rightmost(), false)
+}
+
+// PushBack inserts v at the end and returns the new element.
+func (l *List[T]) PushBack(v T) *Element[T] {
+	return l.PushBackElement(&Element[T]{Value: v})
+}
+
+// PushBackElement inserts e, which is not in the list like the element removed,
+// at the end and returns e. It returns nil when e is in the list.
+func (l *List[T]) PushBackElement(e *Element[T]) *Element[T] {
+	if e.Index() >= 0 {
+		return nil
+	}
+	if back := l.Back(); back != nil {
+		return l.insert(e, back, false)
+	}
+	return l.insert(e, nil, false)
+}
+
+// PushFront inserts v at the top and returns the new element.
+func (l *List[T]) PushFront(v T) *Element[T] {
+	return l.PushFrontElement(&Element[T]{Value: v})
+}
+
+// PushFrontElement inserts e, which is not in the list like the element removed,
+// at the top and returns e. It returns nil when e is in the list.
+func (l *List[T]) PushFrontElement(e *Element[T]) *Element[T] {
+	if e.Index() >= 0 {
+		return nil
+	}
+	if front := l.Front(); front != nil {
+		return l.insert(e, front, true)
+	}
+	return l.insert(e, nil, true)
+}
+
+// Remove removes e from l if it is an element of l and returns e.Value.
+func (l *List[T]) Remove(e *Element[T]) T {
+	if e.list != l || e.Index() < 0 {
+		return e.Value
+	}
+	for e.left != nil && e.right != nil {
+		child := e.left
+		if e.right.priority > child.priority {
+			child = e.right
+		}
+		l.rotateUp(child)
+	}
+	child := e.left
+	if child == nil {
+		child = e.right
+	}
+	p := e.parent
+	if child != nil {
+		child.parent = p
+	}
+	if p == nil {
+		l.root = child
+	} else if p.left == e {
+		p.left = child
+	} else {
+		p.right = child
+	}
+	for q := p; q != nil; q = q.parent {
+		q.size -= e.Weight()
+	}
+	e.left, e.right, e.parent, e.list = nil, nil, nil, nil
+	l.mods++
+	return e.Value
+}
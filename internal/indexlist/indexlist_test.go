@@ -0,0 +1,167 @@
+package indexlist
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func check(t *testing.T, l *List[int], expect []int) {
+	t.Helper()
+	if l.Len() != len(expect) {
+		t.Fatalf("Len: expect %d, but %d", len(expect), l.Len())
+	}
+	i := 0
+	for e := l.Front(); e != nil; e = e.Next() {
+		if e.Value != expect[i] || e.Index() != i || l.At(i) != e {
+			t.Fatalf("[%d]: expect %d, but %d (index %d)", i, expect[i], e.Value, e.Index())
+		}
+		i++
+	}
+	if i != len(expect) {
+		t.Fatalf("Next: expect %d elements, but %d", len(expect), i)
+	}
+	for e := l.Back(); e != nil; e = e.Prev() {
+		i--
+		if e.Value != expect[i] {
+			t.Fatalf("Prev [%d]: expect %d, but %d", i, expect[i], e.Value)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	l := New[int]()
+	var model []int
+	for n := 0; n < 3000; n++ {
+		switch i := rnd.Intn(len(model) + 1); {
+		case len(model) > 0 && rnd.Intn(3) == 0:
+			if i >= len(model) {
+				i = len(model) - 1
+			}
+			e := l.At(i)
+			if v := l.Remove(e); v != model[i] || e.Index() != -1 || e.Next() != nil {
+				t.Fatalf("Remove(%d): %d", i, v)
+			}
+			model = append(model[:i], model[i+1:]...)
+		case i < len(model) && rnd.Intn(2) == 0:
+			l.InsertBefore(n, l.At(i))
+			model = append(model[:i], append([]int{n}, model[i:]...)...)
+		case i > 0:
+			l.InsertAfter(n, l.At(i-1))
+			model = append(model[:i], append([]int{n}, model[i:]...)...)
+		default:
+			l.PushFront(n)
+			model = append([]int{n}, model...)
+		}
+	}
+	check(t, l, model)
+}
+
+func TestInit(t *testing.T) {
+	l := New[int]()
+	e := l.PushBack(1)
+	l.PushBack(2)
+	mods := l.Mods()
+	l.Init()
+	if l.Len() != 0 || e.Index() != -1 || l.Mods() == mods {
+		t.Fatal("the elements are left after Init")
+	}
+	l.Remove(e)
+	l.PushBack(3)
+	check(t, l, []int{3})
+}
+
+func TestInsertElement(t *testing.T) {
+	l := New[int]()
+	first := l.PushBack(1)
+	e := l.PushBack(2)
+	l.PushBack(3)
+	if l.InsertElementAfter(e, first) != nil || l.PushFrontElement(e) != nil {
+		t.Fatal("the element in the list is inserted again")
+	}
+	l.Remove(e)
+	if l.InsertElementAfter(e, l.Back()) != e || e.Index() != 2 {
+		t.Fatal("the element removed is not inserted again")
+	}
+	check(t, l, []int{1, 3, 2})
+	l.Remove(e)
+	if l.PushFrontElement(e) != e || e.Index() != 0 {
+		t.Fatal("the element removed is not pushed again")
+	}
+	check(t, l, []int{2, 1, 3})
+	l.Remove(e)
+	if l.PushBackElement(e) != e || e.Index() != 2 {
+		t.Fatal("the element removed is not pushed back again")
+	}
+	check(t, l, []int{1, 3, 2})
+	l.Init()
+	l.PushBack(4)
+	if l.InsertElementAfter(e, l.Front()) != e {
+		t.Fatal("the element cleared by Init is not inserted again")
+	}
+	check(t, l, []int{4, 2})
+}
+
+func TestWeight(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	l := New[int]()
+	var model []int // the value at each index
+	for n := 0; n < 2000; n++ {
+		switch i := rnd.Intn(len(model) + 1); {
+		case len(model) > 0 && rnd.Intn(3) == 0:
+			if i >= len(model) {
+				i = len(model) - 1
+			}
+			e, offset := l.Locate(i)
+			if e.Value != model[i] || e.Index() != i-offset {
+				t.Fatalf("Locate(%d): %d at %d", i, e.Value, e.Index())
+			}
+			w := 1 + rnd.Intn(4)
+			model = append(model[:i-offset], append(repeat(e.Value, w), model[i-offset+e.Weight():]...)...)
+			l.SetWeight(e, w)
+		case len(model) > 0 && rnd.Intn(4) == 0:
+			if i >= len(model) {
+				i = len(model) - 1
+			}
+			e, offset := l.Locate(i)
+			model = append(model[:i-offset], model[i-offset+e.Weight():]...)
+			l.Remove(e)
+		default:
+			w := 1 + rnd.Intn(4)
+			e := &Element[int]{Value: n}
+			l.SetWeight(e, w)
+			if i == 0 {
+				l.PushFrontElement(e)
+				model = append(repeat(n, w), model...)
+			} else {
+				mark, offset := l.Locate(i - 1)
+				i += mark.Weight() - offset - 1
+				l.InsertElementAfter(e, mark)
+				model = append(model[:i], append(repeat(n, w), model[i:]...)...)
+			}
+		}
+	}
+	if l.Len() != len(model) {
+		t.Fatalf("Len: expect %d, but %d", len(model), l.Len())
+	}
+	i := 0
+	for e := l.Front(); e != nil; e = e.Next() {
+		for j := 0; j < e.Weight(); j++ {
+			if at, offset := l.Locate(i); at != e || offset != j || model[i] != e.Value {
+				t.Fatalf("[%d]: expect %d, but %d", i, model[i], e.Value)
+			}
+			i++
+		}
+		if e.Index() != i-e.Weight() {
+			t.Fatalf("Index: expect %d, but %d", i-e.Weight(), e.Index())
+		}
+	}
+}
+
+func repeat(v, n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = v
+	}
+	return s
+}
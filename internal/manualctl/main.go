@@ -36,12 +36,14 @@ func (c *clipBoard) Write(text string) error {
 
 type ManualCtl struct {
 	ttyadapter.Tty
-	clipBoard
+	// Clipboard is used by readline to kill and yank text.
+	Clipboard readline.Clipboard
 }
 
 func New() (*ManualCtl, error) {
 	mc := &ManualCtl{
-		Tty: &tty8pe.Tty{},
+		Tty:       &tty8pe.Tty{},
+		Clipboard: &clipBoard{},
 	}
 	return mc, mc.Open(nil)
 }
@@ -84,7 +86,7 @@ func (m *ManualCtl) ReadLine(out io.Writer, prompt, defaultStr string, c candida
 		ResetColor:   "\x1B[0m",
 		DefaultColor: "\x1B[0m",
 		PredictColor: predictColor,
-		Clipboard:    &m.clipBoard,
+		Clipboard:    m.Clipboard,
 	}
 	if len(c) > 0 {
 		editor.BindKey(keys.CtrlI, &completion.CmdCompletion2{
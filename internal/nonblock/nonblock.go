@@ -91,30 +91,37 @@ func New[T any](keyGetter func() (string, error),
 }
 
 func (w *NonBlock[T]) GetOr(work func(val T, err error) bool) (string, error) {
-	w.chKeyReq <- struct{}{}
-	if w.noMoreData {
-		res, ok := <-w.chKeyRes
-		if !ok {
-			return "", ErrNoKeyResponse
-		}
-		return res.key, res.err
+	return w.GetOrIdle(work, 0, nil)
+}
+
+// GetOrIdle is the same as GetOr, but it also calls idle once
+// when no key is typed for the duration d. idle is not called when d <= 0.
+func (w *NonBlock[T]) GetOrIdle(work func(val T, err error) bool, d time.Duration, idle func()) (string, error) {
+	var timer <-chan time.Time
+	if d > 0 && idle != nil {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		timer = t.C
 	}
+	w.chKeyReq <- struct{}{}
 	for {
+		chDataRes := w.chDataRes
+		if w.noMoreData {
+			chDataRes = nil
+		}
 		select {
 		case res, ok := <-w.chKeyRes:
 			if !ok {
 				return "", ErrNoKeyResponse
 			}
 			return res.key, res.err
-		case res, ok := <-w.chDataRes:
+		case res, ok := <-chDataRes:
 			if !ok || work == nil || !work(res.val, res.err) {
-				res, ok := <-w.chKeyRes
 				w.noMoreData = true
-				if !ok {
-					return "", ErrNoKeyResponse
-				}
-				return res.key, res.err
 			}
+		case <-timer:
+			timer = nil
+			idle()
 		}
 	}
 }
@@ -0,0 +1,124 @@
+package csvi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nyaosorg/go-readline-ny/keys"
+)
+
+// keyCommands are the names of the built-in commands for Config.KeyBindings
+// and the keys typed to call them by default.
+var keyCommands = map[string][]string{
+	"quit":                   {"q"},
+	"save":                   {"w"},
+	"repaint":                {keys.CtrlL},
+	"reload-encoding":        {"L"},
+	"command-line":           {":"},
+	"page-down":              {keys.CtrlF},
+	"page-up":                {keys.CtrlB},
+	"next-row":               {"j"},
+	"previous-row":           {"k"},
+	"previous-column":        {"h"},
+	"next-column":            {"l"},
+	"beginning-of-row":       {"0"},
+	"end-of-row":             {"$"},
+	"beginning-of-file":      {"<"},
+	"end-of-file":            {">"},
+	"goto":                   {"J"},
+	"next-hunk":              {"}"},
+	"previous-hunk":          {"{"},
+	"diff-get":               {"D"},
+	"review-changes":         {"C"},
+	"problems":               {"E"},
+	"next-problem":           {"g", "e"},
+	"previous-problem":       {"g", "E"},
+	"set-mark":               {"m"},
+	"jump-to-mark":           {"'"},
+	"jump-back":              {keys.CtrlO},
+	"jump-forward":           {keys.CtrlY}, // not Ctrl-I of Vim, which is TAB moving the cursor right
+	"statistics":             {"="},
+	"filter":                 {"F"},
+	"search-forward":         {"/"},
+	"search-backward":        {"?"},
+	"search-next":            {"n"},
+	"search-previous":        {"N"},
+	"search-cell-forward":    {"*"},
+	"search-cell-backward":   {"#"},
+	"insert-row-below":       {"o"},
+	"insert-row-above":       {"O"},
+	"insert-cell":            {"i"},
+	"append-cell":            {"a"},
+	"substitute":             {"s"},
+	"sort":                   {"S"},
+	"edit-cell":              {"r"},
+	"edit-cell-exteditor":    {"R"},
+	"undo":                   {"u"},
+	"redo":                   {keys.CtrlR},
+	"restore-cell":           {"U"},
+	"select-cells":           {"v"},
+	"select-rows":            {"V"},
+	"cancel-selection":       {keys.Escape},
+	"yank-cell":              {"y", "l"},
+	"yank-row":               {"y", "r"},
+	"yank-column":            {"y", "c"},
+	"delete-cell":            {"d", "l"},
+	"delete-row":             {"d", "r"},
+	"delete-column":          {"d", "c"},
+	"paste-after":            {"p"},
+	"paste-before":           {"P"},
+	"paste-over":             {keys.AltP},
+	"paste-clipboard-after":  {"g", "p"},
+	"paste-clipboard-before": {"g", "P"},
+	"paste-clipboard-over":   {"g", keys.AltP},
+	"clear-cell":             {"x"},
+	"toggle-quote":           {"\""},
+	"widen-column":           {"]"},
+	"narrow-column":          {"["},
+	"auto-fit":               {"+"},
+	"freeze-columns":         {"Z"},
+}
+
+// KeyCommandNames returns the names of the built-in commands
+// which can be used as the values of Config.KeyBindings.
+func KeyCommandNames() []string {
+	names := make([]string, 0, len(keyCommands))
+	for name := range keyCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (cfg *Config) checkKeyBindings() error {
+	for key, name := range cfg.KeyBindings {
+		if name == "" || strings.HasPrefix(name, ":") {
+			continue
+		}
+		if _, ok := keyCommands[name]; !ok {
+			return fmt.Errorf("%s: unknown command bound to %q", name, key)
+		}
+	}
+	return nil
+}
+
+// resolveKey returns the key which the built-in commands are looking for
+// instead of the key typed. The rest of the keys of the command are
+// given to the following prompts of MessageAndGetKey.
+// For a binding to a command line like `:w`, it returns ":" and the line.
+func (app *Application) resolveKey(ch string) (string, string) {
+	name, ok := app.KeyBindings[ch]
+	if !ok {
+		return ch, ""
+	}
+	if strings.HasPrefix(name, ":") {
+		return ":", name[1:]
+	}
+	seq := keyCommands[name]
+	if len(seq) <= 0 { // unbound
+		return "", ""
+	}
+	app.pendingKeys = append(app.pendingKeys[:0], seq[1:]...)
+	return seq[0], ""
+}
@@ -16,10 +16,12 @@ func cmdEditCellWith(editor func(string, *Application) (string, error), app *App
 	q := cursor.IsQuoted()
 	app.clearCache()
 	if text, err := editor(cursor.Text(), app); err == nil {
+		before := takeRowImage(app.cursorRow.Row)
 		app.cursorRow.Replace(app.cursorCol, text, app.Mode)
 		if q {
 			*cursor = cursor.Quote(app.Mode)
 		}
+		app.recordRowChange(app.cursorRow.Row, before)
 	} else {
 		return err.Error()
 	}
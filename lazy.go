@@ -0,0 +1,116 @@
+package csvi
+
+import (
+	"io"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// lazyFetch returns the function which reads the rows of Config.Lazy from r.
+// ra reads the rows unloaded again.
+func (cfg *Config) lazyFetch(r io.Reader, ra io.ReaderAt) func() (*uncsv.Row, error) {
+	if cfg.Mode == nil {
+		cfg.Mode = &uncsv.Mode{}
+	}
+	cfg.source = uncsv.NewSource(r, ra, cfg.Mode)
+	return cfg.source.ReadLine
+}
+
+// scanRows calls callback with the elements of the rows in order
+// until it returns false without reading the rows unloaded again.
+// An element may be the run of the rows (see rowList).
+func (app *Application) scanRows(callback func(*rowElement) bool) {
+	for e := app.csvLines.Front(); e != nil; e = e.Next() {
+		if !callback(e) {
+			return
+		}
+	}
+}
+
+// visitRows calls callback with the line numbers and the rows from lnum to the end
+// until it returns false. The rows in the runs are read from the file
+// without being taken out of them, so callback must neither keep nor change them.
+func (app *Application) visitRows(lnum int, callback func(int, *uncsv.Row) bool) {
+	e, offset := app.csvLines.Locate(lnum)
+	for ; e != nil; e, offset = e.Next(), 0 {
+		for ; offset < e.Weight(); offset++ {
+			if !callback(lnum, readRow(e, offset)) {
+				return
+			}
+			lnum++
+		}
+	}
+}
+
+// visitRowsBackward is visitRows from lnum to the top.
+func (app *Application) visitRowsBackward(lnum int, callback func(int, *uncsv.Row) bool) {
+	e, offset := app.csvLines.Locate(lnum)
+	for e != nil {
+		for ; offset >= 0; offset-- {
+			if !callback(lnum, readRow(e, offset)) {
+				return
+			}
+			lnum--
+		}
+		if e = e.Prev(); e != nil {
+			offset = e.Weight() - 1
+		}
+	}
+}
+
+// readRow returns the row loaded offset rows after the first row of the run.
+func readRow(e *rowElement, offset int) *uncsv.Row {
+	if offset <= 0 {
+		e.Value.Load()
+		return e.Value
+	}
+	row, err := e.Value.Following(offset).ReadSource()
+	if err != nil {
+		// as uncsv.Row.Load does
+		return &uncsv.Row{Cell: []uncsv.Cell{{}}}
+	}
+	return row
+}
+
+// dumpRows returns the function which returns the rows except the ghost rows
+// one by one, and nil at the end. The rows in the runs are returned unloaded.
+func (app *Application) dumpRows() func() *uncsv.Row {
+	e, offset := app.csvLines.Front(), 0
+	return func() *uncsv.Row {
+		for e != nil {
+			if offset >= e.Weight() {
+				e, offset = e.Next(), 0
+				continue
+			}
+			row := e.Value
+			if offset > 0 {
+				row = row.Following(offset)
+			}
+			offset++
+			if !app.isGhost(row) {
+				return row
+			}
+		}
+		return nil
+	}
+}
+
+// unloadRows releases the cells of the rows read with Config.Lazy
+// except the rows on the screen and the rows changed.
+func (app *Application) unloadRows() {
+	if app.source == nil {
+		return
+	}
+	keep := map[*uncsv.Row]bool{app.cursorRow.Row: true}
+	e := app.csvLines.Front()
+	for i := 0; i < app.HeaderLines && e != nil; i++ {
+		keep[e.Value] = true
+		e = e.Next()
+	}
+	e = app.startRow.element
+	for i := 0; i <= app.screenHeight && e != nil; i++ {
+		keep[e.Value] = true
+		e = e.Next()
+	}
+	app.source.Unload(func(row *uncsv.Row) bool { return keep[row] })
+}
@@ -0,0 +1,109 @@
+package csvi_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/csvi"
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// numberedRows returns the rows `N,a` of 1 to n.
+func numberedRows(n int) []string {
+	rows := make([]string, 0, n)
+	for i := 1; i <= n; i++ {
+		rows = append(rows, fmt.Sprintf("%d,a\n", i))
+	}
+	return rows
+}
+
+func TestLazy(t *testing.T) {
+	rows := numberedRows(100)
+	path := makeSource(t, "big.csv", strings.Join(rows, ""))
+	// the rows unloaded are read from the file opened first
+	// even after it is replaced by saving
+	instance, err := newTestOptions("-lazy", "-auto",
+		"r|X|:|100|r|Y|w|"+path+"|y|k|r|Z|u|k|r|W|w|"+path+"|q", path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	enable := disableStdout(t)
+	err = instance.Run()
+	enable()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	rows[0] = "X,a\n"
+	rows[97] = "W,a\n"
+	rows[99] = "Y,a\n"
+	checkResult(t, path, strings.Join(rows, ""))
+}
+
+func TestLazyChanges(t *testing.T) {
+	path := makeSource(t, "big.csv", strings.Join(numberedRows(100), ""))
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer fd.Close()
+	var changes []string
+	// the row 100 is the row 99 after the row 50 is deleted
+	cfg := &csvi.Config{
+		Mode:  &uncsv.Mode{Comma: ','},
+		Pilot: &scriptPilot{script: strings.Split("r|X|:|50|d|d|:|99|r|Y|:|changes|q|n", "|")},
+		Lazy:  true,
+		Commands: map[string]func(*csvi.CommandEventArgs) (*csvi.CommandResult, error){
+			"changes": func(e *csvi.CommandEventArgs) (*csvi.CommandResult, error) {
+				for _, c := range e.Changes() {
+					changes = append(changes, fmt.Sprintf("%s %d %v %v", c.Op, c.Row, c.Old, c.New))
+				}
+				return &csvi.CommandResult{}, nil
+			},
+		},
+	}
+	if _, err := cfg.Edit(fd, io.Discard); err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := []string{
+		"update 1 [1 a] [X a]",
+		"delete 50 [50 a] []",
+		"update 100 [100 a] [Y a]",
+	}
+	if strings.Join(changes, "|") != strings.Join(expect, "|") {
+		t.Fatalf("expect %q, but %q", expect, changes)
+	}
+}
+
+func TestLazyRuns(t *testing.T) {
+	// the rows not used are kept as the runs of the rows in the file,
+	// and the results must be the same as without -lazy
+	source := strings.Join(numberedRows(100), "")
+	for _, script := range []string{
+		":|100|<|/|77|r|X|?|3|r|Y|n|r|Z|N|N|r|W",
+		"F|$1>=90|j|r|X|j|j|r|Y|k|r|Z",
+		":|50|d|r|j|d|r|u|r|X",
+		":|60|m|a|<|'|a|r|X|:|70|\x0F|r|Y",
+		":|40|y|r|:|80|p|:|20|P",
+		":|99|o|N|:|10|O|M|:|102|r|Z",
+		":|30|V|j|j|d|k|r|X|u|\x12",
+		":|10|r|X|:|90|r|Y|C|j|U|q",
+	} {
+		var results [2]string
+		for i, args := range [][]string{{"-auto"}, {"-lazy", "-auto"}} {
+			path := filepath.Join(t.TempDir(), "out.csv")
+			testRun(t, strings.NewReader(source), append(args, script+"|w|"+path+"|q|y")...)
+			bin, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			results[i] = string(bin)
+		}
+		if results[0] != results[1] {
+			t.Fatalf("%q: expect %q, but %q", script, results[0], results[1])
+		}
+	}
+}
@@ -2,7 +2,6 @@ package csvi
 
 import (
 	"bufio"
-	"container/list"
 	"context"
 	"errors"
 	"fmt"
@@ -74,9 +73,18 @@ func RevertColor() {
 	headColorStyle.Revert()
 }
 
+var highlightColor = "\x1B[30;43m"
+
+var selectionColor = "\x1B[30;46m"
+
 func MonoChrome() {
 	bodyColorStyle = monoChromeStyle
 	headColorStyle = monoChromeStyle
+	highlightColor = "\x1B[1m"
+	selectionColor = "\x1B[4m"
+	diffAddedColor = ""
+	diffRemovedColor = "\x1B[9m"
+	diffChangedColor = ""
 	ansi.YELLOW = ""
 }
 
@@ -101,7 +109,32 @@ type lineStyle struct {
 	screenWidth  int
 	screenHeight int
 	*colorStyle
-	sep string
+	sep       string
+	highlight func(col int, text, shown string) [][]int
+	selected  func(col int) bool
+	// changed is true for the cell which differs from the old file in the diff mode
+	changed func(col int) bool
+	// rowColor returns the color of the text of the row in the diff mode
+	rowColor func() string
+	// frozen is the number of the columns fixed at the left
+	frozen int
+}
+
+// frozenSep is drawn between the frozen columns and the others.
+const frozenSep = "|"
+
+func decorate(text string, ranges [][]int, on, off string) string {
+	var buffer strings.Builder
+	last := 0
+	for _, r := range ranges {
+		buffer.WriteString(text[last:r[0]])
+		buffer.WriteString(on)
+		buffer.WriteString(text[r[0]:r[1]])
+		buffer.WriteString(off)
+		last = r[1]
+	}
+	buffer.WriteString(text[last:])
+	return buffer.String()
 }
 
 func (style lineStyle) drawLine(
@@ -118,13 +151,15 @@ func (style lineStyle) drawLine(
 	}
 	i := 0
 
+	lineOn, lineOff := style.Even.On, style.Even.Off
 	if reverse {
-		io.WriteString(out, style.Odd.On)
-		defer io.WriteString(out, style.Odd.Off)
-	} else {
-		io.WriteString(out, style.Even.On)
-		defer io.WriteString(out, style.Even.Off)
+		lineOn, lineOff = style.Odd.On, style.Odd.Off
+	}
+	if style.rowColor != nil {
+		lineOn += style.rowColor()
 	}
+	io.WriteString(out, lineOn)
+	defer io.WriteString(out, lineOff)
 	io.WriteString(out, "\x1B[K")
 
 	screenWidth := style.screenWidth
@@ -137,7 +172,7 @@ func (style lineStyle) drawLine(
 		nextI := i + 1
 
 		cw := style.cellWidth(i)
-		for len(field) > 0 && field[0].Text() == "" && nextI != cursorPos {
+		for len(field) > 0 && field[0].Text() == "" && nextI != cursorPos && nextI != style.frozen {
 			cw += style.cellWidth(nextI)
 			field = field[1:]
 			nextI++
@@ -146,40 +181,55 @@ func (style lineStyle) drawLine(
 			cw = screenWidth
 		}
 		text = replaceTable.Replace(text)
-		if i > 0 && style.sep != "" {
+		sep := style.sep
+		if i > 0 && i == style.frozen {
+			sep = frozenSep
+		}
+		if i > 0 && sep != "" {
 			io.WriteString(out, "\x1B[30;1m")
-			io.WriteString(out, style.sep)
-			if reverse {
-				io.WriteString(out, style.Odd.On)
-			} else {
-				io.WriteString(out, style.Even.On)
+			io.WriteString(out, sep)
+			io.WriteString(out, lineOn)
+		}
+		if sep == style.sep {
+			text = truncate(text, cw-sepLen, "\u2026")
+		} else {
+			text = truncate(text, cw-runewidth.StringWidth(sep), "\u2026")
+		}
+		selected := i != cursorPos && style.selected != nil && style.selected(i)
+		if style.highlight != nil {
+			if ranges := style.highlight(i, cursor.Text(), text); len(ranges) > 0 {
+				base := lineOn
+				if i == cursorPos {
+					base = style.Cursor.On
+				} else if selected {
+					base = selectionColor
+				}
+				text = decorate(text, ranges, highlightColor, base)
 			}
 		}
-		text = truncate(text, cw-sepLen, "\u2026")
 		if i == cursorPos {
 			io.WriteString(out, style.Cursor.On)
+		} else if selected {
+			io.WriteString(out, selectionColor)
 		}
-		if cursor.Modified() {
+		underline := cursor.Modified() || (style.changed != nil && style.changed(i))
+		if underline {
 			io.WriteString(out, ansi.UNDERLINE_ON)
 		}
 		io.WriteString(out, text)
-		if cursor.Modified() {
+		if underline {
 			io.WriteString(out, ansi.UNDERLINE_OFF)
 		}
-		if i == cursorPos {
+		if i == cursorPos || selected {
 			io.WriteString(out, "\x1B[K")
-			if reverse {
-				io.WriteString(out, style.Odd.On)
-			} else {
-				io.WriteString(out, style.Even.On)
-			}
+			io.WriteString(out, lineOn)
 		}
 		screenWidth -= cw
 		if screenWidth <= 0 {
 			break
 		}
 		fmt.Fprintf(out, "\x1B[%dG", sum(style.cellWidth, 0, nextI)+1)
-		if i == cursorPos {
+		if i == cursorPos || selected {
 			io.WriteString(out, "\x1B[K")
 		}
 		i = nextI
@@ -237,14 +287,15 @@ func cellsAfter(cells []uncsv.Cell, n int) []uncsv.Cell {
 }
 
 type Application struct {
-	csvLines     *list.List
-	removedRows  []*uncsv.Row
+	csvLines     *rowList
+	removedRows  []*rowElement
 	out          io.Writer
 	dirty        int
 	lastSavePath string
 	startRow     *RowPtr
 	cursorRow    *RowPtr
 	startCol     int
+	frozenCols   int
 	cursorCol    int
 	screenWidth  int
 	screenHeight int
@@ -254,6 +305,36 @@ type Application struct {
 	fetchFunc    func() (*uncsv.Row, error)
 	tryFetchFunc func() (*uncsv.Row, error)
 	ctrlC        *ScopedInterrupt
+	history      history
+	filter       *rowFilter
+	problemCache *problemCache
+	// searchPattern is the last pattern searched and highlighted
+	searchPattern *searchPattern
+	selection     *selection
+	// clipText is the text copied last to the clipboard
+	clipText string
+	// pendingKeys are the keys of the command bound by Config.KeyBindings
+	// which are not read yet
+	pendingKeys []string
+	marks       map[string]position
+	jumps       []position
+	jumpIndex   int
+	// jumpFrom is the position before the jump of the current command
+	jumpFrom *position
+	// diff is the old rows paired with the rows in the diff mode
+	diff map[*uncsv.Row]*diffEntry
+	// ghostLnums is the line numbers of the ghost rows, or nil until they are counted
+	ghostLnums []int
+	// name is shown on the tab line when two or more buffers are edited
+	name    string
+	buffers *bufferList
+	// base is the rows as loaded or saved last to find the changes
+	base    map[*uncsv.Row]*baseRow
+	baseLen int
+	// swapChanges is history.changes when the swap file was written
+	swapChanges int
+	swapTime    time.Time
+	swapWritten bool
 	*Config
 }
 
@@ -261,17 +342,22 @@ func (app *Application) CurrentRow() *RowPtr { return app.cursorRow }
 func (app *Application) CurrentCol() int     { return app.cursorCol }
 
 func (cfg *Config) newApplication(out io.Writer) *Application {
-	return &Application{
+	app := &Application{
 		headCache: map[int]string{},
 		bodyCache: map[int]string{},
 		Config:    cfg,
-		csvLines:  list.New(),
+		csvLines:  newRowList(),
 		out:       out,
 		ctrlC:     NewScopedInterrupt(),
 	}
+	app.csvLines.onSplit = app.splitBase
+	return app
 }
 
 func (app *Application) Close() {
+	if app.stopFetch != nil {
+		app.stopFetch()
+	}
 	app.ctrlC.Close()
 }
 
@@ -299,12 +385,39 @@ func (app *Application) draw() int {
 	header := app.Front()
 
 	cellWidth := func(n int) int {
-		return app.CellWidth.Get(n + app.startCol)
+		return app.CellWidth.Get(app.columnAt(n))
+	}
+	var highlight func(int, string, string) [][]int
+	if p := app.searchPattern; p != nil {
+		highlight = func(n int, text, shown string) [][]int {
+			return p.highlight(app.columnAt(n), text, shown)
+		}
+	}
+	// lnum and row are the line number and the row being drawn
+	var lnum int
+	var row *uncsv.Row
+	var selected func(int) bool
+	if app.selection != nil {
+		selected = func(n int) bool {
+			return app.isSelected(lnum, app.columnAt(n))
+		}
+	}
+	var changed func(int) bool
+	var rowColor func() string
+	if app.diff != nil {
+		changed = func(n int) bool {
+			return app.isCellChanged(row, app.columnAt(n))
+		}
+		rowColor = func() string {
+			return app.diffColor(row)
+		}
 	}
 	if h := app.HeaderLines; h > 0 {
 		enum := func(callback func([]uncsv.Cell) bool) {
 			for i := 0; i < h && header != nil; i++ {
-				if !callback(cellsAfter(header.Cell, app.startCol)) {
+				lnum = header.Index()
+				row = header.Row
+				if !callback(app.cellsOnScreen(header.Cell)) {
 					return
 				}
 				header = app.nextOrFetch(header)
@@ -316,10 +429,15 @@ func (app *Application) draw() int {
 			screenHeight: h,
 			colorStyle:   &headColorStyle,
 			sep:          app.OutputSep,
-		}.drawPage(enum, app.cursorCol-app.startCol, app.cursorRow.lnum, app.headCache, app.out)
+			highlight:    highlight,
+			selected:     selected,
+			changed:      changed,
+			rowColor:     rowColor,
+			frozen:       app.frozenCols,
+		}.drawPage(enum, app.cursorOnScreen(), app.cursorRow.Index(), app.headCache, app.out)
 	}
 	startRow := app.startRow
-	if startRow.lnum < app.HeaderLines {
+	if startRow.Index() < app.HeaderLines {
 		for i := 0; i < app.HeaderLines && startRow != nil; i++ {
 			startRow = app.nextOrFetch(startRow)
 		}
@@ -331,7 +449,9 @@ func (app *Application) draw() int {
 	// print body
 	enum := func(callback func([]uncsv.Cell) bool) {
 		for p != nil {
-			if !callback(cellsAfter(p.Cell, app.startCol)) {
+			lnum = p.Index()
+			row = p.Row
+			if !callback(app.cellsOnScreen(p.Cell)) {
 				return
 			}
 			p = app.nextOrFetch(p)
@@ -351,12 +471,22 @@ func (app *Application) draw() int {
 		screenHeight: app.screenHeight - 1,
 		colorStyle:   style,
 		sep:          app.OutputSep,
-	}.drawPage(enum, app.cursorCol-app.startCol, app.cursorRow.lnum-startRow.lnum, app.bodyCache, app.out)
+		highlight:    highlight,
+		selected:     selected,
+		changed:      changed,
+		rowColor:     rowColor,
+		frozen:       app.frozenCols,
+	}.drawPage(enum, app.cursorOnScreen(), app.visibleDistance(startRow, app.cursorRow, app.screenHeight), app.bodyCache, app.out)
 	return app.lfCount
 }
 
 func (app *Application) MessageAndGetKey(message string) (string, error) {
 	fmt.Fprintf(app, "%s\r%s%s ", ansi.YELLOW, message, ansi.ERASE_LINE)
+	if len(app.pendingKeys) > 0 {
+		ch := app.pendingKeys[0]
+		app.pendingKeys = app.pendingKeys[1:]
+		return ch, nil
+	}
 	io.WriteString(app, ansi.CURSOR_ON)
 	ch, err := app.GetKey()
 	io.WriteString(app, ansi.CURSOR_OFF)
@@ -407,6 +537,20 @@ func (app *Application) printStatusLine() {
 	if app.Mode.HasBom() {
 		n += first(io.WriteString(app.out, "[BOM]"))
 	}
+	if app.selection != nil {
+		if app.selection.mode == selectRows {
+			n += first(io.WriteString(app.out, "[VISUAL ROWS]"))
+		} else {
+			n += first(io.WriteString(app.out, "[VISUAL]"))
+		}
+	}
+	if app.diff != nil {
+		n += first(io.WriteString(app.out, app.diffStatus()))
+	}
+	if app.filter != nil {
+		matched, total := app.filterCount()
+		n += first(fmt.Fprintf(app.out, "[filtered %d of %d]", matched, total))
+	}
 	if app.Mode.NonUTF8 {
 		if app.Mode.IsUTF16LE() {
 			n += first(io.WriteString(app.out, "[16LE]"))
@@ -417,10 +561,12 @@ func (app *Application) printStatusLine() {
 		}
 	}
 	if 0 <= app.cursorCol && app.cursorCol < len(app.cursorRow.Cell) {
+		lnum, total := app.cursorRow.Index()+1, app.cursorRow.list.Len()
+		if app.diff != nil {
+			lnum, total = app.savedPosition()
+		}
 		n += first(fmt.Fprintf(app.out, "(%d,%d/%d): ",
-			app.cursorCol+1,
-			app.cursorRow.lnum+1,
-			app.cursorRow.list.Len()))
+			app.cursorCol+1, lnum, total))
 		var buffer strings.Builder
 		buffer.WriteString(app.cursorRow.Cell[app.cursorCol].SourceText(app.Mode))
 		if app.cursorCol < len(app.cursorRow.Cell)-1 {
@@ -478,6 +624,51 @@ type Config struct {
 	OutputSep       string
 	SavePath        string
 	ExtEditor       func(string, *Application) (string, error)
+	// Clipboard receives the text copied by yank and delete commands
+	// in addition to OSC 52, and is read by `gp`.
+	Clipboard Clipboard
+	// ClipboardCSV makes the clipboard text separated by Mode.Comma instead of TAB.
+	ClipboardCSV bool
+	// NoOSC52 stops sending the copied text to the terminal with OSC 52
+	// for the terminals which do not support it or ask to allow it every time.
+	NoOSC52 bool
+	// FreezeColumns is the number of the leading columns which do not scroll.
+	FreezeColumns int
+	// Commands are the named commands called from the `:` prompt.
+	// They take precedence over the built-in ones.
+	Commands map[string]func(*CommandEventArgs) (*CommandResult, error)
+	// KeyBindings maps a key to the name of a built-in command (see KeyCommandNames)
+	// or a command line starting with ":". An empty name disables the key.
+	KeyBindings map[string]string
+	// DiffBase is the rows of the old file compared with the rows edited.
+	// The rows only in the old file are shown but not saved.
+	DiffBase []*uncsv.Row
+	// DiffKeyColumn is the column (counted from 1) whose values pair the rows
+	// of both files. With 0, rows are paired by their order.
+	DiffKeyColumn int
+	// Patch is applied to the rows read first as changes not saved yet.
+	// Editing fails when a row does not match it.
+	Patch []Change
+	// SourcePath is the file which the rows are read from.
+	// Saving to it asks what to do when another program has rewritten it
+	// since it was loaded.
+	SourcePath string
+	// SwapPath is the file where the rows not saved yet are written
+	// while editing (see SwapPath function). Empty disables it.
+	SwapPath string
+	// Follow keeps reading the rows appended after EOF like `tail -f`.
+	// The rows are read-only while following.
+	Follow bool
+	// Lazy keeps only the rows on the screen and the rows changed in memory
+	// when the data source is an io.ReaderAt like *os.File.
+	// The other rows are read again from it when they are needed
+	// and copied from it when they are saved, so it must not be changed
+	// while editing. The commands which look at all the rows
+	// (for example, searching) read them temporarily.
+	Lazy      bool
+	stamp     *fileStamp
+	source    *uncsv.Source
+	stopFetch func()
 }
 
 func (cfg Config) validate(row *RowPtr, col int, text string) (string, error) {
@@ -485,7 +676,7 @@ func (cfg Config) validate(row *RowPtr, col int, text string) (string, error) {
 		return text, nil
 	}
 	return cfg.OnCellValidated(&CellValidatedEvent{
-		Row:  row.lnum,
+		Row:  row.Index(),
 		Col:  col,
 		Text: text,
 	})
@@ -495,6 +686,23 @@ func (cfg Config) Edit(dataSource io.Reader, ttyOut io.Writer) (*Result, error)
 	if dataSource == nil {
 		return cfg.edit(nil, ttyOut)
 	}
+	ra, _ := dataSource.(io.ReaderAt)
+	regular := isRegularFile(dataSource)
+	dataSource = cfg.watchSource(dataSource)
+	if cfg.Follow {
+		if cfg.Mode == nil {
+			cfg.Mode = &uncsv.Mode{}
+		}
+		var fetch func() (*uncsv.Row, error)
+		fetch, cfg.stopFetch = followFetch(dataSource, cfg.Mode)
+		return cfg.edit(fetch, ttyOut)
+	}
+	if cfg.Lazy && ra != nil {
+		return cfg.edit(cfg.lazyFetch(dataSource, ra), ttyOut)
+	}
+	if regular {
+		return cfg.edit(cfg.parallelFetch(dataSource), ttyOut)
+	}
 	bufDataSource, ok := dataSource.(*bufio.Reader)
 	if !ok {
 		bufDataSource = bufio.NewReader(dataSource)
@@ -519,30 +727,34 @@ const (
 	msgReadOnly      = "Read Only Mode !"
 	msgProtectHeader = "Header is protected"
 	msgColumnFixed   = "The order of Columns is fixed !"
+	msgRemovedRow    = "The row exists only in the old file (D: restore it)"
 )
 
-func (cfg *Config) checkWriteProtect(cursorRow *RowPtr) string {
-	if cfg.ProtectHeader && cursorRow.lnum < cfg.HeaderLines {
+func (app *Application) checkWriteProtect(cursorRow *RowPtr) string {
+	if app.ProtectHeader && cursorRow.Index() < app.HeaderLines {
 		return msgProtectHeader
 	}
-	if cfg.ReadOnly {
+	if app.ReadOnly {
 		return msgReadOnly
 	}
+	if app.isGhost(cursorRow.Row) {
+		return msgRemovedRow
+	}
 	return ""
 }
 
-func (cfg *Config) checkWriteProtectAndColumn(cursorRow *RowPtr) string {
-	if m := cfg.checkWriteProtect(cursorRow); m != "" {
+func (app *Application) checkWriteProtectAndColumn(cursorRow *RowPtr) string {
+	if m := app.checkWriteProtect(cursorRow); m != "" {
 		return m
 	}
-	if cfg.FixColumn {
+	if app.FixColumn {
 		return msgColumnFixed
 	}
 	return ""
 }
 
 func (app *Application) readlineAndValidate(prompt, text string, row *RowPtr, col int) (string, error) {
-	candidates := makeCandidate(row.lnum-1, col, row)
+	candidates := app.makeCandidate(col, row)
 	for {
 		var err error
 		text, err = app.Config.Pilot.ReadLine(app.out, prompt, text, candidates)
@@ -558,13 +770,20 @@ func (app *Application) readlineAndValidate(prompt, text string, row *RowPtr, co
 }
 
 func (app *Application) cmdQuit() (*Result, error) {
-	if !app.ReadOnly && app.IsDirty() {
-		ch, err := app.MessageAndGetKey(`Quit: Save changes ? ["y": save, "n": quit without saving, other: cancel]`)
+	for _, b := range app.allBuffers() {
+		if b.ReadOnly || !b.IsDirty() {
+			continue
+		}
+		prompt := `Quit: Save changes ? ["y": save, "n": quit without saving, other: cancel]`
+		if app.buffers.multiple() {
+			prompt = fmt.Sprintf(`Quit: Save changes of "%s" ? ["y": save, "n": discard, other: cancel]`, b.name)
+		}
+		ch, err := app.MessageAndGetKey(prompt)
 		if err != nil {
 			return nil, err
 		}
 		if ch == "y" || ch == "Y" {
-			message, err := app.cmdSave()
+			message, err := b.cmdSave()
 			if err != nil {
 				return nil, err
 			}
@@ -574,92 +793,216 @@ func (app *Application) cmdQuit() (*Result, error) {
 		}
 	}
 	io.WriteString(app.out, "\n")
-	return &Result{Application: app}, nil
+	return app.result(), nil
 }
 
 func (app *Application) tryFetch() (*uncsv.Row, error) {
 	if app.tryFetchFunc == nil {
 		return nil, io.EOF
 	}
-	return app.tryFetchFunc()
+	row, err := app.tryFetchFunc()
+	if errors.Is(err, errFollowIdle) {
+		app.stopFollowFetch()
+	}
+	return row, err
+}
+
+// stopFollowFetch stops drawing from loading rows once the rows at the start
+// are read in the follow mode. The rows appended later are loaded only by
+// the main loop, which moves the cursor on the last row onto them.
+func (app *Application) stopFollowFetch() {
+	app.tryFetchFunc = nil
 }
 
 func (app *Application) nextOrFetch(p *RowPtr) *RowPtr {
-	if next := p.Next(); next != nil {
-		return next
-	}
-	if row, err := app.tryFetch(); err == nil || errors.Is(err, io.EOF) {
-		if row != nil && !row.IsZero() {
-			app.push(row)
+	lnum := p.Index() + 1
+	for {
+		if next := app.visibleFrom(lnum, false); next >= 0 {
+			return app.seek(next)
+		}
+		lnum = app.Len()
+		row, err := app.tryFetch()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil
 		}
-		return p.Next()
+		if row == nil || row.IsZero() {
+			return nil
+		}
+		app.load(row)
 	}
-	return nil
 }
 
-func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Result, error) {
-	defer perm.RestoreAll()
-
-	if cfg.KeyMap == nil {
-		cfg.KeyMap = make(map[string]func(*KeyEventArgs) (*CommandResult, error))
+// scrollToCursor updates startRow and startCol so that the cursor is on the screen.
+func (app *Application) scrollToCursor() {
+	if app.cursorRow.Index() < app.startRow.Index() || !app.isVisible(app.startRow) {
+		app.startRow = app.cursorRow.Clone()
+	} else if app.visibleDistance(app.startRow, app.cursorRow, app.screenHeight-1) >= app.screenHeight-1 {
+		app.startRow = app.cursorRow.Clone()
+		for i := 0; i < app.screenHeight-2; i++ {
+			prev := app.prevVisible(app.startRow)
+			if prev == nil {
+				break
+			}
+			app.startRow = prev
+		}
 	}
-
-	mode := cfg.Mode
-	if mode == nil {
-		mode = &uncsv.Mode{}
-		cfg.Mode = mode
+	if app.startCol < app.frozenCols {
+		app.startCol = app.frozenCols
 	}
-
-	cellWidth := cfg.CellWidth
-	if cellWidth == nil {
-		cellWidth = NewCellWidth()
-		cfg.CellWidth = cellWidth
+	if app.cursorCol < app.frozenCols {
+		// the frozen columns are always on the screen
+	} else if app.cursorCol < app.startCol {
+		app.startCol = app.cursorCol
+	} else {
+		cellWidth := app.CellWidth
+		if cellWidth == nil {
+			cellWidth = NewCellWidth()
+			app.CellWidth = cellWidth
+		}
+		for {
+			w := sum(cellWidth.Get, 0, app.frozenCols) + sum(cellWidth.Get, app.startCol, app.cursorCol+1)
+			if w <= app.screenWidth || app.startCol >= app.cursorCol {
+				break
+			}
+			app.startCol++
+		}
 	}
+}
 
-	pilot := cfg.Pilot
-	if pilot == nil {
-		var err error
-		pilot, err = manualctl.New()
-		if err != nil {
-			return nil, err
-		}
-		defer pilot.Close()
-		cfg.Pilot = pilot
+// openBuffer makes the Application for the rows read by fetch
+// and starts to read them in the background.
+func (cfg *Config) openBuffer(fetch func() (*uncsv.Row, error), out io.Writer) (*Application, *nonblock.NonBlock[*uncsv.Row], error) {
+	if cfg.Mode == nil {
+		cfg.Mode = &uncsv.Mode{}
+	}
+	if cfg.CellWidth == nil {
+		cfg.CellWidth = NewCellWidth()
+	}
+	if cfg.Follow {
+		cfg.ReadOnly = true
 	}
 	app := cfg.newApplication(out)
-	defer app.Close()
 	if fetch != nil {
 		if row, err := fetch(); err == nil && !row.IsZero() {
-			app.push(row)
+			app.load(row)
 		} else {
-			newRow := uncsv.NewRow(mode)
+			newRow := uncsv.NewRow(cfg.Mode)
 			app.push(&newRow)
-			fetch = nil
+			if !errors.Is(err, errFollowIdle) {
+				fetch = nil
+			}
 		}
 	} else {
-		newRow := uncsv.NewRow(mode)
+		newRow := uncsv.NewRow(cfg.Mode)
 		app.push(&newRow)
 	}
 	app.startRow = app.Front()
 	app.startCol = 0
 	app.cursorCol = 0
 	app.cursorRow = app.Front()
+	app.frozenCols = cfg.FreezeColumns
 
-	lastSearch := searchForward
-	lastSearchRev := searchBackward
-	lastWord := ""
-	var lastWidth, lastHeight int
-
-	keyWorker := nonblock.New(pilot.GetKey, fetch)
-	defer keyWorker.Close()
+	keyWorker := nonblock.New(cfg.Pilot.GetKey, fetch)
 
 	app.fetchFunc = keyWorker.Fetch
 	app.tryFetchFunc = func() (*uncsv.Row, error) {
 		return keyWorker.TryFetch(100 * time.Millisecond)
 	}
+	if err := app.checkSwap(); err != nil {
+		keyWorker.Close()
+		app.Close()
+		return nil, nil, fmt.Errorf("swap: %w", err)
+	}
+	if cfg.DiffBase != nil {
+		if err := app.applyDiff(); err != nil {
+			keyWorker.Close()
+			app.Close()
+			return nil, nil, err
+		}
+		app.startRow = app.Front()
+		app.cursorRow = app.Front()
+	}
+	if cfg.Patch != nil {
+		if err := app.applyPatch(cfg.Patch); err != nil {
+			keyWorker.Close()
+			app.Close()
+			return nil, nil, err
+		}
+		app.startRow = app.Front()
+		app.cursorRow = app.Front()
+		app.endCommand()
+	}
+	return app, keyWorker, nil
+}
+
+func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Result, error) {
+	return cfg.editBuffers([]*Config{cfg}, []func() (*uncsv.Row, error){fetch}, nil, out)
+}
+
+// editBuffers edits the rows of fetches with configs. cfg has the settings shared by them.
+func (cfg *Config) editBuffers(configs []*Config, fetches []func() (*uncsv.Row, error), names []string, out io.Writer) (rc *Result, err error) {
+	defer perm.RestoreAll()
+
+	if cfg.KeyMap == nil {
+		cfg.KeyMap = make(map[string]func(*KeyEventArgs) (*CommandResult, error))
+	}
+	if err := cfg.checkKeyBindings(); err != nil {
+		return nil, err
+	}
+
+	pilot := cfg.Pilot
+	if pilot == nil {
+		mc, err := manualctl.New()
+		if err != nil {
+			return nil, err
+		}
+		defer mc.Close()
+		if cfg.Clipboard != nil {
+			mc.Clipboard = cfg.Clipboard
+		}
+		pilot = mc
+		cfg.Pilot = pilot
+	}
+
+	buffers := &bufferList{}
+	defer buffers.close()
+	defer func() {
+		// the swap files are left only when the editing does not end normally
+		// (for example, the terminal is closed) with the latest changes.
+		for _, app := range buffers.apps {
+			if rc != nil && err == nil {
+				app.removeSwap()
+			} else {
+				app.updateSwap(true)
+			}
+		}
+	}()
+	for i, c := range configs {
+		c.Pilot = pilot
+		c.KeyMap = cfg.KeyMap
+		app, keyWorker, err := c.openBuffer(fetches[i], out)
+		if err != nil {
+			return nil, err
+		}
+		if i < len(names) {
+			app.name = names[i]
+		}
+		app.buffers = buffers
+		buffers.apps = append(buffers.apps, app)
+		buffers.workers = append(buffers.workers, keyWorker)
+	}
+	app := buffers.apps[0]
+	keyWorker := buffers.workers[0]
+	cfg = app.Config
+	mode := cfg.Mode
+	cellWidth := cfg.CellWidth
+
+	lastSearch := app.searchForward
+	lastSearchRev := app.searchBackward
+	var lastSortKeys []sortKey
+	var lastWidth, lastHeight int
 
 	var allScreenHeight int
-	var err error
 	app.screenWidth, allScreenHeight, err = pilot.Size()
 	if err != nil {
 		return nil, err
@@ -668,9 +1011,17 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 		s, _ := cutStrInWidth(title, app.screenWidth-1)
 		fmt.Fprintln(out, s)
 	}
+	if buffers.multiple() {
+		fmt.Fprintln(out)
+		allScreenHeight--
+	}
 	message := cfg.Message
 	var killbuffer pasteFunc
 	for {
+		if buffers.multiple() {
+			io.WriteString(out, "\x1B[A")
+			buffers.drawTabLine(app, out)
+		}
 		app.screenHeight = allScreenHeight - len(cfg.Titles)
 		app.screenHeight -= cfg.HeaderLines
 		if lastWidth != app.screenWidth || lastHeight != app.screenHeight {
@@ -681,11 +1032,12 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 		}
 
 		app.draw()
+		app.unloadRows()
 
 		io.WriteString(out, ansi.YELLOW)
 		if message != "" {
 			io.WriteString(out, truncate(message, app.screenWidth-1, ""))
-		} else if 0 <= app.cursorRow.lnum && app.cursorRow.lnum < app.Len() {
+		} else if 0 <= app.cursorRow.Index() && app.cursorRow.Index() < app.Len() {
 			app.printStatusLine()
 		}
 		io.WriteString(out, ansi.RESET)
@@ -708,14 +1060,30 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 			}
 		*/
 
-		ch, err := keyWorker.GetOr(func(row *uncsv.Row, err error) bool {
+		var loaded, followed bool
+		ch, err := keyWorker.GetOrIdle(func(row *uncsv.Row, err error) bool {
+			if errors.Is(err, errFollowIdle) {
+				app.stopFollowFetch()
+			}
 			if !row.IsZero() {
-				app.push(row)
+				// the cursor on the last row follows the rows appended
+				atBottom := cfg.Follow && app.cursorRow.Index() >= app.Len()-1
+				app.load(row)
+				loaded = true
+				if atBottom {
+					app.cursorRow = app.backVisible()
+					followed = true
+				}
 			}
-			if message == "" && (errors.Is(err, io.EOF) || time.Now().After(displayUpdateTime)) {
-				if app.csvLines.Len() <= allScreenHeight {
+			if message == "" && (errors.Is(err, io.EOF) ||
+				loaded && (errors.Is(err, errFollowIdle) || time.Now().After(displayUpdateTime))) {
+				if followed {
+					app.scrollToCursor()
+					app.repaint()
+				} else if app.csvLines.Len() <= allScreenHeight {
 					app.repaint()
 				}
+				loaded, followed = false, false
 				io.WriteString(out, "\r"+ansi.YELLOW)
 				app.printStatusLine()
 				io.WriteString(out, ansi.RESET)
@@ -723,11 +1091,21 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 				displayUpdateTime = time.Now().Add(time.Second / interval)
 			}
 			return !errors.Is(err, io.EOF)
+		}, app.swapDelay(), func() {
+			// write the latest changes to the swap file while no key is typed
+			if err := app.updateSwap(false); err != nil && message == "" {
+				message = err.Error()
+				io.WriteString(out, "\r"+ansi.YELLOW)
+				io.WriteString(out, truncate(message, app.screenWidth-1, ""))
+				io.WriteString(out, ansi.RESET)
+				io.WriteString(out, ansi.ERASE_SCRN_AFTER)
+			}
 		})
 		if err != nil {
 			return nil, err
 		}
 		message = ""
+		app.beginCommand()
 
 		if handler, ok := cfg.KeyMap[ch]; ok {
 			e := &KeyEventArgs{
@@ -737,10 +1115,12 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 			}
 			cmdResult, err := handler(e)
 			if err != nil || cmdResult.Quit {
-				return &Result{Application: app}, err
+				return app.result(), err
 			}
 			message = cmdResult.Message
 		} else {
+			var line string
+			ch, line = app.resolveKey(ch)
 			switch ch {
 			case keys.CtrlL:
 				app.clearCache()
@@ -759,7 +1139,19 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 					p.Restore(mode)
 				}
 				app.resetSoftDirty()
+				app.resetHistory()
+				app.rebase()
 				app.clearCache()
+			case ":":
+				cmdResult, err := app.cmdColon(line)
+				if err != nil {
+					message = err.Error()
+					break
+				}
+				if cmdResult.Quit {
+					return app.result(), nil
+				}
+				message = cmdResult.Message
 			case "q", keys.AltQ:
 				if rc, err := app.cmdQuit(); err != nil {
 					message = err.Error()
@@ -768,32 +1160,32 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 				}
 			case keys.CtrlF, keys.PageDown:
 				for i := 0; i < app.screenHeight-1; i++ {
-					if next := app.cursorRow.Next(); next != nil {
+					if next := app.nextVisible(app.cursorRow); next != nil {
 						app.cursorRow = next
 					} else {
 						break
 					}
-					if next := app.startRow.Next(); next != nil {
+					if next := app.nextVisible(app.startRow); next != nil {
 						app.startRow = next
 					}
 				}
 			case keys.CtrlB, keys.PageUp:
 				for i := 0; i < app.screenHeight-1; i++ {
-					if prev := app.cursorRow.Prev(); prev != nil {
+					if prev := app.prevVisible(app.cursorRow); prev != nil {
 						app.cursorRow = prev
 					} else {
 						break
 					}
-					if prev := app.startRow.Prev(); prev != nil {
+					if prev := app.prevVisible(app.startRow); prev != nil {
 						app.startRow = prev
 					}
 				}
 			case "j", keys.Down, keys.CtrlN, keys.Enter:
-				if next := app.cursorRow.Next(); next != nil {
+				if next := app.nextVisible(app.cursorRow); next != nil {
 					app.cursorRow = next
 				}
 			case "k", keys.Up, keys.CtrlP:
-				if prev := app.cursorRow.Prev(); prev != nil {
+				if prev := app.prevVisible(app.cursorRow); prev != nil {
 					app.cursorRow = prev
 				}
 			case "h", keys.Left, keys.ShiftTab:
@@ -807,111 +1199,195 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 			case "$", keys.CtrlE:
 				app.cursorCol = len(app.cursorRow.Cell) - 1
 			case "g":
-				ch, err := app.MessageAndGetKey("g- [\"g\": move to the beginning of file ]")
-				if err == nil && ch == "g" {
-					app.cursorRow = app.Front()
+				ch, err := app.MessageAndGetKey("g- [\"g\": move to the beginning of file, \"p\"/\"P\"/M-p: paste from the clipboard, \"e\"/\"E\": next/previous problem ]")
+				if err != nil {
+					break
+				}
+				switch ch {
+				case "g":
+					app.rememberJump()
+					app.cursorRow = app.frontVisible()
 					app.startRow = app.Front()
 					app.cursorCol = 0
 					app.startCol = 0
+				case "p", "P", keys.AltP:
+					pt := pasteAfter
+					if ch == "P" {
+						pt = pasteBefore
+					} else if ch == keys.AltP {
+						pt = pasteOver
+					}
+					if err := app.pasteFromClipboard(pt); err != nil {
+						message = err.Error()
+						break
+					}
+					app.repaint()
+					app.clearCache()
+					app.setHardDirty()
+				case "e", "E":
+					if m, err := app.nextProblem(ch == "E"); err != nil {
+						message = err.Error()
+					} else {
+						message = m
+					}
 				}
 			case "<":
-				app.cursorRow = app.Front()
+				app.rememberJump()
+				app.cursorRow = app.frontVisible()
 				app.startRow = app.Front()
 				app.cursorCol = 0
 				app.startCol = 0
 			case ">", "G":
-				app.cursorRow = app.Back()
+				app.rememberJump()
+				app.cursorRow = app.backVisible()
+			case "}":
+				if err := app.nextHunk(); err != nil {
+					message = err.Error()
+				}
+			case "{":
+				if err := app.prevHunk(); err != nil {
+					message = err.Error()
+				}
+			case "C":
+				message = app.cmdReviewChanges()
+			case "E":
+				message = app.cmdProblems()
+			case "D":
+				if err := app.diffGet(false); err != nil {
+					message = err.Error()
+				}
+			case "m":
+				ch, err := app.MessageAndGetKey("Mark ? [a-z]")
+				if err != nil {
+					break
+				}
+				if err := app.setMark(ch); err != nil {
+					message = err.Error()
+				}
+			case "'":
+				ch, err := app.MessageAndGetKey(`Jump to mark ? [a-z, "'": the position before the last jump]`)
+				if err != nil {
+					break
+				}
+				if err := app.jumpToMark(ch); err != nil {
+					message = err.Error()
+				}
+			case keys.CtrlO:
+				if err := app.jumpBack(); err != nil {
+					message = err.Error()
+				}
+			case keys.CtrlY:
+				if err := app.jumpForward(); err != nil {
+					message = err.Error()
+				}
+			case "J":
+				message = app.cmdGoto()
+			case "=":
+				message = app.cmdStats()
+			case "F":
+				message = app.cmdFilter()
 			case "n":
-				if lastWord == "" {
+				if app.searchPattern == nil {
 					break
 				}
 				ctx, cancel := app.withSlowOperation("Searching...")
-				r, c, err := lastSearch(ctx, app.cursorRow, app.cursorCol, lastWord)
+				r, c, err := lastSearch(ctx, app.cursorRow, app.cursorCol, app.searchPattern)
 				cancel()
 				if err != nil {
 					message = err.Error()
 					break
 				}
 				if r == nil {
-					message = fmt.Sprintf("%s: not found", lastWord)
+					message = fmt.Sprintf("%s: not found", app.searchPattern.source)
 					break
 				}
+				app.rememberJump()
 				app.cursorRow = r
 				app.cursorCol = c
 			case "N":
-				if lastWord == "" {
+				if app.searchPattern == nil {
 					break
 				}
 				ctx, cancel := app.withSlowOperation("Searching...")
-				r, c, err := lastSearchRev(ctx, app.cursorRow, app.cursorCol, lastWord)
+				r, c, err := lastSearchRev(ctx, app.cursorRow, app.cursorCol, app.searchPattern)
 				cancel()
 				if err != nil {
 					message = err.Error()
 					break
 				}
 				if r == nil {
-					message = fmt.Sprintf("%s: not found", lastWord)
+					message = fmt.Sprintf("%s: not found", app.searchPattern.source)
 					break
 				}
+				app.rememberJump()
 				app.cursorRow = r
 				app.cursorCol = c
 			case "*", "#":
 				app.clearCache()
-				if ch == "*" || ch == "#" {
-					lastWord = app.cursorRow.Cell[app.cursorCol].Text()
-				}
+				app.searchPattern = newExactPattern(app.cursorRow.Cell[app.cursorCol].Text())
 				if ch == "*" {
-					lastSearch = searchExactForward
-					lastSearchRev = searchExactBackward
+					lastSearch = app.searchForward
+					lastSearchRev = app.searchBackward
 				} else {
-					lastSearch = searchExactBackward
-					lastSearchRev = searchExactForward
+					lastSearch = app.searchBackward
+					lastSearchRev = app.searchForward
 				}
 				ctx, cancel := app.withSlowOperation("Searching...")
-				r, c, err := lastSearch(ctx, app.cursorRow, app.cursorCol, lastWord)
+				r, c, err := lastSearch(ctx, app.cursorRow, app.cursorCol, app.searchPattern)
 				cancel()
 				if err != nil {
 					message = err.Error()
 					break
 				}
 				if r == nil {
-					message = fmt.Sprintf("%s: not found", lastWord)
+					message = fmt.Sprintf("%s: not found", app.searchPattern.source)
 					break
 				}
+				app.rememberJump()
 				app.cursorRow = r
 				app.cursorCol = c
 			case "/", "?":
-				var err error
 				app.clearCache()
-				lastWord, err = pilot.ReadLine(out, ch, "", nil)
+				word, err := pilot.ReadLine(out, ch, "", nil)
 				if err != nil {
 					if err != readline.CtrlC {
 						message = err.Error()
 					}
 					break
 				}
+				if word == "" {
+					if app.searchPattern == nil {
+						break
+					}
+				} else if pattern, err := parseSearchPattern(word, app.cursorCol); err != nil {
+					message = err.Error()
+					break
+				} else {
+					app.searchPattern = pattern
+				}
 				if ch == "/" {
-					lastSearch = searchForward
-					lastSearchRev = searchBackward
+					lastSearch = app.searchForward
+					lastSearchRev = app.searchBackward
 				} else {
-					lastSearch = searchBackward
-					lastSearchRev = searchForward
+					lastSearch = app.searchBackward
+					lastSearchRev = app.searchForward
 				}
 				ctx, cancel := app.withSlowOperation("Searching...")
-				r, c, err := lastSearch(ctx, app.cursorRow, app.cursorCol, lastWord)
+				r, c, err := lastSearch(ctx, app.cursorRow, app.cursorCol, app.searchPattern)
 				cancel()
 				if err != nil {
 					message = err.Error()
 					break
 				}
 				if r == nil {
-					message = fmt.Sprintf("%s: not found", lastWord)
+					message = fmt.Sprintf("%s: not found", app.searchPattern.source)
 					break
 				}
+				app.rememberJump()
 				app.cursorRow = r
 				app.cursorCol = c
 			case "o":
-				if cfg.ProtectHeader && app.cursorRow.lnum+1 < cfg.HeaderLines {
+				if cfg.ProtectHeader && app.cursorRow.Index()+1 < cfg.HeaderLines {
 					message = msgProtectHeader
 					break
 				}
@@ -922,7 +1398,9 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 				newRow := uncsv.NewRow(mode)
 				newRow.Term = app.cursorRow.Term
 				if app.cursorRow.Term == "" {
+					before := takeRowImage(app.cursorRow.Row)
 					app.cursorRow.Term = mode.DefaultTerm
+					app.recordRowChange(app.cursorRow.Row, before)
 				}
 				if cfg.FixColumn {
 					for len(newRow.Cell) < len(app.cursorRow.Cell) {
@@ -930,6 +1408,7 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 					}
 				}
 				app.cursorRow = app.cursorRow.InsertAfter(&newRow)
+				app.recordInsertRow(app.cursorRow)
 				app.repaint()
 				app.clearCache()
 				newCol := app.cursorCol
@@ -937,11 +1416,13 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 					newCol = len(app.cursorRow.Cell) - 1
 				}
 				if text, err := app.readlineAndValidate("new line>", "", app.cursorRow, newCol); err == nil {
+					before := takeRowImage(app.cursorRow.Row)
 					app.cursorRow.Replace(newCol, text, mode)
+					app.recordRowChange(app.cursorRow.Row, before)
 				}
 				app.setHardDirty()
 			case "O":
-				if m := cfg.checkWriteProtect(app.cursorRow); m != "" {
+				if m := app.checkWriteProtect(app.cursorRow); m != "" {
 					message = m
 					break
 				}
@@ -953,6 +1434,7 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 					}
 				}
 				app.cursorRow = app.cursorRow.InsertBefore(&newRow)
+				app.recordInsertRow(app.cursorRow)
 				if startPrevP != nil {
 					app.startRow = startPrevP.Next()
 				} else {
@@ -965,29 +1447,34 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 					newCol = len(app.cursorRow.Cell) - 1
 				}
 				if text, err := app.readlineAndValidate("new line>", "", app.cursorRow, newCol); err == nil {
+					before := takeRowImage(app.cursorRow.Row)
 					app.cursorRow.Replace(newCol, text, mode)
+					app.recordRowChange(app.cursorRow.Row, before)
 				}
 				app.setHardDirty()
 			case "i":
-				if m := cfg.checkWriteProtectAndColumn(app.cursorRow); m != "" {
+				if m := app.checkWriteProtectAndColumn(app.cursorRow); m != "" {
 					message = m
 					break
 				}
 				app.clearCache()
 				if text, err := app.readlineAndValidate("insert cell>", "", app.cursorRow, app.cursorCol); err == nil {
+					before := takeRowImage(app.cursorRow.Row)
 					if cells := app.cursorRow.Cell; len(cells) == 1 && cells[0].Text() == "" {
 						app.cursorRow.Replace(app.cursorCol, text, mode)
 					} else {
 						app.cursorRow.Insert(app.cursorCol, text, mode)
 						app.cursorCol++
 					}
+					app.recordRowChange(app.cursorRow.Row, before)
 					app.setHardDirty()
 				}
 			case "a":
-				if m := cfg.checkWriteProtectAndColumn(app.cursorRow); m != "" {
+				if m := app.checkWriteProtectAndColumn(app.cursorRow); m != "" {
 					message = m
 					break
 				}
+				before := takeRowImage(app.cursorRow.Row)
 				if cells := app.cursorRow.Cell; len(cells) == 1 && cells[0].Text() == "" {
 					// current column is the last one and it is empty
 					app.clearCache()
@@ -1007,18 +1494,57 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 						app.cursorRow.Replace(app.cursorCol, text, mode)
 					}
 				}
+				app.recordRowChange(app.cursorRow.Row, before)
 				app.setHardDirty()
+			case "s":
+				message = app.cmdSubstitute()
+			case "S":
+				keys, err := app.askSortKeys(lastSortKeys)
+				if err != nil {
+					if err != errCanceled {
+						message = err.Error()
+					}
+					break
+				}
+				lastSortKeys = keys
+				message = app.cmdSort(keys)
 			case "R":
 				message = cmdEditCellExtEditor(app)
 			case "r", keys.F2:
 				message = cmdEditCell(app)
 			case "u":
+				message = app.undo()
+			case keys.CtrlR:
+				message = app.redo()
+			case "U":
+				before := takeRowImage(app.cursorRow.Row)
 				modifiedBefore := app.cursorRow.Cell[app.cursorCol].Modified()
 				app.cursorRow.Cell[app.cursorCol].Restore(mode)
+				app.recordRowChange(app.cursorRow.Row, before)
 				app.updateSoftDirty(modifiedBefore, false)
 			case "Y":
 				killbuffer = app.yankCurrentRow(app.cursorRow)
+				if err := app.copyRowToClipboard(); err != nil {
+					message = err.Error()
+				}
+			case "v", keys.CtrlV:
+				app.toggleSelection(selectCells)
+			case "V":
+				app.toggleSelection(selectRows)
+			case keys.Escape:
+				app.selection = nil
 			case "y":
+				if app.selection != nil {
+					killbuffer = app.yankSelection()
+					if err := app.copySelection(); err != nil {
+						message = err.Error()
+					}
+					top, _, left, _ := app.selectionRange()
+					app.cursorRow = app.seek(top)
+					app.cursorCol = left
+					app.selection = nil
+					break
+				}
 				ch, err := app.MessageAndGetKey(`Yank ? ["l"/"v"/SPACE/TAB/C-F/→: cell, "y"/"r": row, "|"/"c": column]`)
 				if err != nil {
 					message = err.Error()
@@ -1027,13 +1553,35 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 				switch ch {
 				case "l", "v", " ", "\t", keys.CtrlF, keys.Right:
 					killbuffer = app.yankCurrentCell(app.cursorRow, app.cursorCol)
+					err = app.copyCellToClipboard()
 				case "y", "r":
 					killbuffer = app.yankCurrentRow(app.cursorRow)
+					err = app.copyRowToClipboard()
 				case "|", "c":
 					killbuffer = app.yankCurrentColumn(app.cursorCol)
+					err = app.copyColumnToClipboard()
+				}
+				if err != nil {
+					message = err.Error()
 				}
 			case "d":
-				if m := cfg.checkWriteProtect(app.cursorRow); m != "" {
+				if app.selection != nil {
+					if err := app.copySelection(); err != nil {
+						message = err.Error()
+					}
+					paste, err := app.removeSelection()
+					if err != nil {
+						message = err.Error()
+						break
+					}
+					killbuffer = paste
+					app.selection = nil
+					app.repaint()
+					app.clearCache()
+					app.setHardDirty()
+					break
+				}
+				if m := app.checkWriteProtect(app.cursorRow); m != "" {
 					message = m
 					break
 				}
@@ -1048,24 +1596,30 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 				}
 				switch ch {
 				case "l", "v", " ", "\t", keys.CtrlF, keys.Right:
-					if m := cfg.checkWriteProtectAndColumn(app.cursorRow); m != "" {
+					if m := app.checkWriteProtectAndColumn(app.cursorRow); m != "" {
 						message = m
 						break
 					}
+					err = app.copyCellToClipboard()
 					killbuffer = app.removeCurrentCell(app.cursorRow, app.cursorCol)
 				case "d", "r":
+					err = app.copyRowToClipboard()
 					killbuffer = app.removeCurrentRow(&app.startRow, &app.cursorRow)
 					app.repaint()
 					app.clearCache()
 				case "|", "c":
-					if m := cfg.checkWriteProtectAndColumn(app.cursorRow); m != "" {
+					if m := app.checkWriteProtectAndColumn(app.cursorRow); m != "" {
 						message = m
 						break
 					}
+					err = app.copyColumnToClipboard()
 					killbuffer = app.removeCurrentColumn(app.cursorCol)
 					app.repaint()
 					app.clearCache()
 				}
+				if err != nil {
+					message = err.Error()
+				}
 				app.setHardDirty()
 			case "p", "P", keys.AltP:
 				if killbuffer == nil {
@@ -1085,10 +1639,19 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 				app.clearCache()
 				app.setHardDirty()
 			case "x":
-				if m := cfg.checkWriteProtect(app.cursorRow); m != "" {
+				if app.selection != nil {
+					if err := app.clearSelection(); err != nil {
+						message = err.Error()
+						break
+					}
+					app.selection = nil
+					break
+				}
+				if m := app.checkWriteProtect(app.cursorRow); m != "" {
 					message = m
 					break
 				}
+				before := takeRowImage(app.cursorRow.Row)
 				cursor := &app.cursorRow.Cell[app.cursorCol]
 				modifiedBefore := cursor.Modified()
 				q := cursor.IsQuoted()
@@ -1096,9 +1659,11 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 				if q {
 					*cursor = cursor.Quote(mode)
 				}
+				app.recordRowChange(app.cursorRow.Row, before)
 				modifiedAfter := cursor.Modified()
 				app.updateSoftDirty(modifiedBefore, modifiedAfter)
 			case "\"":
+				before := takeRowImage(app.cursorRow.Row)
 				cursor := &app.cursorRow.Cell[app.cursorCol]
 				modifiedBefore := cursor.Modified()
 				if cursor.IsQuoted() {
@@ -1106,6 +1671,7 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 				} else {
 					*cursor = cursor.Quote(mode)
 				}
+				app.recordRowChange(app.cursorRow.Row, before)
 				modifiedAfter := cursor.Modified()
 				app.updateSoftDirty(modifiedBefore, modifiedAfter)
 			case "w":
@@ -1115,6 +1681,21 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 					message = msg
 				}
 				app.clearCache()
+			case "Z":
+				message = app.toggleFreeze()
+			case "+":
+				cellWidth.Auto = !cellWidth.Auto
+				if cellWidth.Auto {
+					cellWidth.ResetFit()
+					app.Each(func(row *uncsv.Row) bool {
+						cellWidth.Fit(row.Cell)
+						return true
+					})
+					message = "Auto-fit widths: on"
+				} else {
+					message = "Auto-fit widths: off"
+				}
+				app.clearCache()
 			case "]":
 				if w := cellWidth.Get(app.cursorCol); w < 40 {
 					cellWidth.Set(app.cursorCol, w+1)
@@ -1132,31 +1713,23 @@ func (cfg *Config) edit(fetch func() (*uncsv.Row, error), out io.Writer) (*Resul
 		} else if app.cursorCol >= L {
 			app.cursorCol = L - 1
 		}
-		if app.cursorRow.lnum < app.startRow.lnum {
-			app.startRow = app.cursorRow.Clone()
-		} else if app.cursorRow.lnum >= app.startRow.lnum+app.screenHeight-1 {
-			goal := app.cursorRow.lnum - (app.screenHeight - 1) + 1
-			for app.startRow = app.cursorRow.Clone(); app.startRow.lnum > goal; {
-				app.startRow = app.startRow.Prev()
-			}
-		}
-		if app.cursorCol < app.startCol {
-			app.startCol = app.cursorCol
-		} else {
-			cellWidth := cfg.CellWidth
-			if cellWidth == nil {
-				cellWidth = NewCellWidth()
-				cfg.CellWidth = cellWidth
-			}
-			for {
-				w := sum(cellWidth.Get, app.startCol, app.cursorCol+1)
-				if w <= app.screenWidth {
-					break
-				}
-				app.startCol++
-			}
+		app.pendingKeys = app.pendingKeys[:0]
+		app.commitJump()
+		app.endCommand()
+		app.scrollToCursor()
+		if err := app.updateSwap(false); err != nil && message == "" {
+			message = err.Error()
 		}
 		app.rewind()
+		if next := buffers.apps[buffers.current]; next != app {
+			next.screenWidth = app.screenWidth
+			app = next
+			keyWorker = buffers.workers[buffers.current]
+			cfg = app.Config
+			mode = cfg.Mode
+			cellWidth = cfg.CellWidth
+			lastWidth = 0
+		}
 	}
 }
 
@@ -0,0 +1,130 @@
+package csvi
+
+import (
+	"errors"
+)
+
+const jumpListSize = 100
+
+// position is a cell remembered by marks and the jump list.
+// It refers to the element of the row instead of the line number
+// so that it follows the row when rows above it are inserted, deleted or sorted.
+type position struct {
+	element *rowElement
+	col     int
+}
+
+var (
+	errMarkNotSet  = errors.New("Mark not set")
+	errMarkDeleted = errors.New("The row of the mark was deleted")
+	errMarkHidden  = errors.New("The row of the mark is hidden by the filter")
+	errNoJump      = errors.New("No more positions in the jump list")
+)
+
+func (app *Application) currentPosition() position {
+	return position{element: app.cursorRow.element, col: app.cursorCol}
+}
+
+// rowPtrOf returns the pointer of the element in O(log n)
+// or nil when it is no longer in the list.
+// For the run of Config.Lazy, it is the pointer of the first row.
+func (app *Application) rowPtrOf(e *rowElement) *RowPtr {
+	lnum := e.Index()
+	if lnum < 0 {
+		return nil
+	}
+	e = app.csvLines.split(e, 0)
+	return newRowPtr(e, lnum, app.csvLines.Mods(), app.csvLines)
+}
+
+// moveTo moves the cursor to pos as a jump.
+func (app *Application) moveTo(pos position) error {
+	p := app.rowPtrOf(pos.element)
+	if p == nil {
+		return errMarkDeleted
+	}
+	if !app.isVisible(p) {
+		return errMarkHidden
+	}
+	app.gotoCell(p.Index(), pos.col)
+	return nil
+}
+
+func (app *Application) setMark(name string) error {
+	if len(name) != 1 || name[0] < 'a' || name[0] > 'z' {
+		return errors.New("Mark must be a-z")
+	}
+	if app.marks == nil {
+		app.marks = map[string]position{}
+	}
+	app.marks[name] = app.currentPosition()
+	return nil
+}
+
+// jumpToMark moves to the mark. `'` is the position before the last jump.
+func (app *Application) jumpToMark(name string) error {
+	if name == "'" {
+		return app.jumpBack()
+	}
+	pos, ok := app.marks[name]
+	if !ok {
+		return errMarkNotSet
+	}
+	app.rememberJump()
+	return app.moveTo(pos)
+}
+
+// rememberJump keeps the current position until commitJump
+// so that it is added to the jump list when the cursor moves.
+// Commands moving far away call it before moving.
+func (app *Application) rememberJump() {
+	if app.jumpFrom == nil {
+		pos := app.currentPosition()
+		app.jumpFrom = &pos
+	}
+}
+
+// commitJump adds the position remembered by rememberJump to the jump list
+// when the cursor has moved after it.
+func (app *Application) commitJump() {
+	from := app.jumpFrom
+	app.jumpFrom = nil
+	if from == nil || *from == app.currentPosition() {
+		return
+	}
+	app.jumps = append(app.jumps[:app.jumpIndex], *from)
+	if len(app.jumps) > jumpListSize {
+		app.jumps = app.jumps[len(app.jumps)-jumpListSize:]
+	}
+	app.jumpIndex = len(app.jumps)
+}
+
+// jumpBack moves to the older position in the jump list.
+// Positions of deleted rows are skipped.
+func (app *Application) jumpBack() error {
+	if app.jumpIndex >= len(app.jumps) {
+		// keep the current position to come back with jumpForward
+		app.jumps = append(app.jumps, app.currentPosition())
+		app.jumpIndex = len(app.jumps) - 1
+	}
+	current := app.currentPosition()
+	for i := app.jumpIndex - 1; i >= 0; i-- {
+		if app.jumps[i] != current && app.moveTo(app.jumps[i]) == nil {
+			app.jumpIndex = i
+			return nil
+		}
+	}
+	return errNoJump
+}
+
+// jumpForward moves to the newer position in the jump list.
+func (app *Application) jumpForward() error {
+	current := app.currentPosition()
+	for i := app.jumpIndex + 1; i < len(app.jumps); i++ {
+		if app.jumps[i] != current && app.moveTo(app.jumps[i]) == nil {
+			app.jumpIndex = i
+			return nil
+		}
+	}
+	return errNoJump
+}
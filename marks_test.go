@@ -0,0 +1,29 @@
+package csvi_test
+
+import (
+	"testing"
+)
+
+func TestMarks(t *testing.T) {
+	src := "a\nb\nc\nd"
+	testCase(t, src, "m|a|G|'|a|r|X", "X\nb\nc\nd")
+	// the mark follows the row when a row is inserted above it
+	testCase(t, src, "j|j|m|a|<|O|N|G|'|a|r|X", "N\na\nb\nX\nd")
+	// the mark of a deleted row does not move the cursor
+	testCase(t, src, "j|m|a|d|d|'|a|r|X", "a\nX\nd")
+	testCase(t, src, "G|'|b|r|X", "a\nb\nc\nX")
+	// the mark of a deleted row is valid again after undo
+	testCase(t, src, "j|m|a|d|d|u|G|'|a|r|X", "a\nX\nc\nd")
+	// the mark follows the row when the rows are sorted
+	testCase(t, "n\nc\nb\na", "j|m|a|S|s|<|'|a|r|X", "n\na\nb\nX")
+}
+
+func TestJumpList(t *testing.T) {
+	src := "a\nb\nc\nd"
+	testCase(t, src, "G|\x0F|r|X", "X\nb\nc\nd")
+	testCase(t, src, "G|\x0F|\x19|r|X", "a\nb\nc\nX")
+	testCase(t, src, "G|'|'|r|X", "X\nb\nc\nd")
+	testCase(t, src, "/|c|\x0F|r|X", "X\nb\nc\nd")
+	testCase(t, src, "j|:|4|<|\x0F|\x0F|r|X", "a\nX\nc\nd")
+	testCase(t, src, "j|:|4|<|\x0F|\x0F|\x19|\x19|r|X", "X\nb\nc\nd")
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/mattn/go-runewidth"
 
 	"github.com/hymkor/csvi/candidate"
+	"github.com/hymkor/csvi/uncsv"
 )
 
 func cutStrInWidth(s string, cellwidth int) (string, int) {
@@ -47,113 +48,87 @@ func cutStrInWidth(s string, cellwidth int) (string, int) {
 	return s, w
 }
 
-func makeCandidate(row, col int, cursor *RowPtr) candidate.Candidate {
+func (app *Application) makeCandidate(col int, cursor *RowPtr) candidate.Candidate {
 	result := candidate.Candidate(make([]string, 0, 100))
 	set := make(map[string]struct{})
 	count := 0
-	for ; cursor != nil; cursor = cursor.Prev() {
+	app.visitRowsBackward(cursor.Index(), func(_ int, row *uncsv.Row) bool {
 		count++
-		if col >= len(cursor.Cell) {
-			if count == 1 {
-				continue
-			} else {
-				break
-			}
+		if col >= len(row.Cell) {
+			return count == 1
 		}
-		value := cursor.Cell[col].Text()
+		value := row.Cell[col].Text()
 		if value == "" {
-			if count == 1 {
-				continue
-			} else {
-				break
-			}
+			return count == 1
 		}
 		if _, ok := set[value]; !ok {
 			result = append(result, value)
 			set[value] = struct{}{}
 			if len(set) > 100 {
-				break
+				return false
 			}
 		}
-	}
+		return true
+	})
 	if len(result) <= 0 {
 		result = append(result, "")
 	}
 	return result
 }
 
-func searchForward(ctx context.Context, cursor *RowPtr, c int, target string) (*RowPtr, int, error) {
-	c++
-	for cursor != nil {
-		if err := ctx.Err(); err != nil {
-			return cursor, c, err
-		}
-		for c < len(cursor.Cell) {
-			if strings.Contains(cursor.Cell[c].Text(), target) {
-				return cursor, c, nil
-			}
-			c++
-		}
-		cursor = cursor.Next()
-		c = 0
-	}
-	return nil, c, nil
-}
-
-func searchExactForward(ctx context.Context, cursor *RowPtr, c int, target string) (*RowPtr, int, error) {
+// searchForward finds the cell matching the pattern after the cell c of the cursor
+// skipping the rows hidden by the filter.
+// The rows not matching are not taken out of the runs of Config.Lazy.
+func (app *Application) searchForward(ctx context.Context, cursor *RowPtr, c int, pattern *searchPattern) (*RowPtr, int, error) {
+	found := -1
 	c++
-	for cursor != nil {
-		if err := ctx.Err(); err != nil {
-			return cursor, c, err
-		}
-		for c < len(cursor.Cell) {
-			if strings.EqualFold(cursor.Cell[c].Text(), target) {
-				return cursor, c, nil
+	var err error
+	app.visitRows(cursor.Index(), func(lnum int, row *uncsv.Row) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		if app.isVisibleAt(lnum, row) {
+			for ; c < len(row.Cell); c++ {
+				if pattern.matchCell(c, row.Cell[c].Text()) {
+					found = lnum
+					return false
+				}
 			}
-			c++
 		}
-		cursor = cursor.Next()
 		c = 0
+		return true
+	})
+	if err != nil || found < 0 {
+		return nil, c, err
 	}
-	return nil, c, nil
-}
-
-func searchBackward(ctx context.Context, cursor *RowPtr, c int, target string) (*RowPtr, int, error) {
-	c--
-	for {
-		if err := ctx.Err(); err != nil {
-			return cursor, c, err
-		}
-		for c >= 0 {
-			if strings.Contains(cursor.Cell[c].Text(), target) {
-				return cursor, c, nil
-			}
-			c--
-		}
-		cursor = cursor.Prev()
-		if cursor == nil {
-			return nil, c, nil
-		}
-		c = len(cursor.Cell) - 1
-	}
+	return app.seek(found), c, nil
 }
 
-func searchExactBackward(ctx context.Context, cursor *RowPtr, c int, target string) (*RowPtr, int, error) {
+// searchBackward is searchForward toward the top.
+func (app *Application) searchBackward(ctx context.Context, cursor *RowPtr, c int, pattern *searchPattern) (*RowPtr, int, error) {
+	found := -1
+	start := cursor.Index()
 	c--
-	for {
-		if err := ctx.Err(); err != nil {
-			return cursor, c, err
-		}
-		for c >= 0 {
-			if strings.EqualFold(cursor.Cell[c].Text(), target) {
-				return cursor, c, nil
+	var err error
+	app.visitRowsBackward(start, func(lnum int, row *uncsv.Row) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		if lnum != start {
+			c = len(row.Cell) - 1
+		}
+		if app.isVisibleAt(lnum, row) {
+			for ; c >= 0; c-- {
+				if pattern.matchCell(c, row.Cell[c].Text()) {
+					found = lnum
+					return false
+				}
 			}
-			c--
-		}
-		cursor = cursor.Prev()
-		if cursor == nil {
-			return nil, c, nil
 		}
-		c = len(cursor.Cell) - 1
+		return true
+	})
+	if err != nil || found < 0 {
+		return nil, c, err
 	}
+	return app.seek(found), c, nil
 }
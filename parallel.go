@@ -0,0 +1,30 @@
+package csvi
+
+import (
+	"io"
+	"io/fs"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// isRegularFile reports whether r reads a file on disk, not a pipe
+// whose rows should be shown as soon as they are written.
+func isRegularFile(r io.Reader) bool {
+	f, ok := r.(interface{ Stat() (fs.FileInfo, error) })
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	return err == nil && stat.Mode().IsRegular()
+}
+
+// parallelFetch returns the function which reads the rows of the file r
+// parsing them on multiple goroutines (see uncsv.ParallelReader).
+func (cfg *Config) parallelFetch(r io.Reader) func() (*uncsv.Row, error) {
+	if cfg.Mode == nil {
+		cfg.Mode = &uncsv.Mode{}
+	}
+	p := uncsv.NewParallelReader(r, cfg.Mode)
+	cfg.stopFetch = p.Close
+	return p.ReadLine
+}
@@ -0,0 +1,63 @@
+package csvi_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	// big enough to be split into chunks
+	rows := make([]string, 0, 30000)
+	for i := 1; i <= cap(rows); i++ {
+		rows = append(rows, fmt.Sprintf("%d,\"a\r\nb\",%s\r\n", i, strings.Repeat("c", i%20)))
+	}
+	path := makeSource(t, "big.csv", strings.Join(rows, ""))
+	output := filepath.Join(t.TempDir(), "output.csv")
+	instance, err := newTestOptions("-auto",
+		"r|X|:|30000|o|Y|w|"+output+"|q|y", path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	enable := disableStdout(t)
+	err = instance.Run()
+	enable()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	rows[0] = "X,\"a\r\nb\",c\r\n"
+	rows = append(rows, "Y\r\n")
+	checkResult(t, output, strings.Join(rows, ""))
+}
+
+// BenchmarkOpen is test/benchmark.ps1 with the rows like 27OSAKA.CSV
+// to compare the time to load the file.
+func BenchmarkOpen(b *testing.B) {
+	const count = 100000
+	var source strings.Builder
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&source, "27%03d,\"%03d  \",\"%07d\",\"ｵｵｻｶﾌ\",\"ｵｵｻｶｼｷﾀｸ\",\"ｳﾒﾀﾞ%d\",\"大阪府\",\"大阪市北区\",\"梅田%d丁目\",0,0,1,0,0,0\r\n",
+			i%1000, i%1000, 5300000+i, i, i)
+	}
+	path := makeSource(b, "27OSAKA.CSV", source.String())
+	b.SetBytes(int64(source.Len()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// go to the last row so that all the rows are loaded
+		instance, err := newTestOptions("-auto",
+			fmt.Sprintf("l|l|l|l|l|l|l|l|l|l|l|l|l|l|:|%d|q|y", count), path)
+		if err != nil {
+			b.Fatal(err.Error())
+		}
+		enable := disableStdout(b)
+		err = instance.Run()
+		enable()
+		if err != nil {
+			b.Fatal(err.Error())
+		}
+	}
+	b.ReportMetric(float64(count*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
@@ -0,0 +1,252 @@
+package csvi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/hymkor/go-safewrite"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// Change is a line of the patch written by the review of the changes
+// and applied by Config.Patch. A patch is a sequence of them as JSON lines.
+type Change struct {
+	// Op is "update", "insert" or "delete"
+	Op string `json:"op"`
+
+	// Row is the row counted from 1 in the file before the changes.
+	// For "insert", it is the row after which the new row is put (0 is the top).
+	Row int `json:"row"`
+
+	// Old is the texts of the cells before "update" and "delete"
+	Old []string `json:"old,omitempty"`
+
+	// New is the texts of the cells after "update" and "insert"
+	New []string `json:"new,omitempty"`
+}
+
+// ReadPatch reads the patch of JSON lines.
+func ReadPatch(r io.Reader) ([]Change, error) {
+	var changes []Change
+	dec := json.NewDecoder(r)
+	for dec.More() {
+		var c Change
+		if err := dec.Decode(&c); err != nil {
+			return nil, fmt.Errorf("patch line %d: %w", len(changes)+1, err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// WritePatch writes the changes as JSON lines.
+func WritePatch(w io.Writer, changes []Change) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for i := range changes {
+		if err := enc.Encode(&changes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Changes returns the changes not saved yet.
+func (app *Application) Changes() []Change {
+	changes := app.collectChanges()
+	result := make([]Change, 0, len(changes))
+	for _, c := range changes {
+		result = append(result, c.Change)
+	}
+	return result
+}
+
+// writePatchFile writes the changes not saved yet to fname. "-" is STDOUT.
+func (app *Application) writePatchFile(fname string) (string, error) {
+	changes := app.Changes()
+	if len(changes) <= 0 {
+		return "", errors.New("No changes")
+	}
+	if fname == "-" {
+		return "Output to STDOUT", WritePatch(os.Stdout, changes)
+	}
+	fd, err := safewrite.Open(fname, app.confirmOverwrite)
+	if err != nil {
+		return "", err
+	}
+	if err := WritePatch(fd, changes); err != nil {
+		fd.Close()
+		return "", err
+	}
+	if err := fd.Close(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Wrote %d changes to \"%s\"", len(changes), fname), nil
+}
+
+// check tests the change against the n rows. rowAt returns the row at the index.
+func (c *Change) check(n int, rowAt func(int) *uncsv.Row) error {
+	switch c.Op {
+	case "update", "delete":
+		if c.Row < 1 || c.Row > n {
+			return fmt.Errorf("row %d: out of range", c.Row)
+		}
+		row := rowAt(c.Row - 1)
+		row.Load()
+		if !equalTexts(cellTexts(row.Cell), c.Old) {
+			return fmt.Errorf("row %d: does not match the old cells", c.Row)
+		}
+	case "insert":
+		if c.Row < 0 || c.Row > n {
+			return fmt.Errorf("row %d: out of range", c.Row)
+		}
+		if len(c.New) <= 0 {
+			return fmt.Errorf("row %d: no cells to insert", c.Row)
+		}
+	default:
+		return fmt.Errorf("%s: unknown operation", c.Op)
+	}
+	return nil
+}
+
+// setTexts makes the cells of the row have the texts.
+func (app *Application) setTexts(row *uncsv.Row, texts []string) {
+	for len(row.Cell) > len(texts) {
+		row.Delete(len(row.Cell) - 1)
+	}
+	for i, text := range texts {
+		if i >= len(row.Cell) {
+			row.Insert(i, text, app.Mode)
+		} else if row.Cell[i].Text() != text {
+			row.Replace(i, text, app.Mode)
+		}
+	}
+}
+
+// patchTargets returns the elements of the rows which the patch refers to
+// by the row numbers counted from 1 except the ghost rows, and the number of the rows.
+// Only those rows are taken out of the runs of Config.Lazy.
+func (app *Application) patchTargets(patch []Change) (map[int]*rowElement, int) {
+	targets := map[int]*rowElement{}
+	var wanted []int
+	for _, c := range patch {
+		if _, ok := targets[c.Row]; !ok && c.Row > 0 {
+			targets[c.Row] = nil
+			wanted = append(wanted, c.Row)
+		}
+	}
+	sort.Ints(wanted)
+	type place struct {
+		row    int
+		e      *rowElement
+		offset int
+	}
+	var places []place
+	n := 0
+	app.scanRows(func(e *rowElement) bool {
+		if app.isGhost(e.Value) {
+			return true
+		}
+		for len(wanted) > 0 && wanted[0] <= n+e.Weight() {
+			places = append(places, place{row: wanted[0], e: e, offset: wanted[0] - n - 1})
+			wanted = wanted[1:]
+		}
+		n += e.Weight()
+		return true
+	})
+	// the later rows of a run are taken out first to keep the offsets of the others
+	for i := len(places) - 1; i >= 0; i-- {
+		targets[places[i].row] = app.csvLines.split(places[i].e, places[i].offset)
+	}
+	return targets, n
+}
+
+// applyPatch applies the patch to all the rows as one undoable change.
+// Nothing is changed when any row does not match the patch.
+func (app *Application) applyPatch(patch []Change) error {
+	ctx, cancel := app.withSlowOperation("Applying the patch...")
+	app.fetchAll(ctx)
+	err := ctx.Err()
+	cancel()
+	if err != nil {
+		return errors.New("Applying the patch interrupted")
+	}
+	elements, n := app.patchTargets(patch)
+	rowAt := func(i int) *uncsv.Row { return elements[i+1].Value }
+	// a row is updated or deleted at most once
+	changed := map[int]string{}
+	inserts, deletes := 0, 0
+	for i := range patch {
+		c := &patch[i]
+		if err := c.check(n, rowAt); err != nil {
+			return fmt.Errorf("patch line %d: %w", i+1, err)
+		}
+		if c.Op == "insert" {
+			inserts++
+			continue
+		}
+		if op, ok := changed[c.Row]; ok {
+			return fmt.Errorf("patch line %d: row %d: %s after %s of the same row", i+1, c.Row, c.Op, op)
+		}
+		changed[c.Row] = c.Op
+		if c.Op == "delete" {
+			deletes++
+		}
+	}
+	if inserts <= 0 && deletes >= n {
+		return errors.New("The patch deletes all the rows")
+	}
+	for _, c := range patch {
+		if c.Op == "update" {
+			row := rowAt(c.Row - 1)
+			before := takeRowImage(row)
+			app.setTexts(row, c.New)
+			app.recordRowChange(row, before)
+		}
+	}
+	// the rows inserted after the same row keep the order in the patch
+	lastInserted := map[int]*rowElement{}
+	for _, c := range patch {
+		if c.Op != "insert" {
+			continue
+		}
+		newRow := uncsv.NewRowFromStringSlice(app.Mode, c.New)
+		anchor, ok := lastInserted[c.Row]
+		if !ok && c.Row > 0 {
+			anchor = elements[c.Row]
+		}
+		var inserted *RowPtr
+		if anchor == nil {
+			app.csvLines.PushFront(&newRow)
+			inserted = app.Front()
+		} else {
+			p := app.rowPtrOf(anchor)
+			if p.Next() == nil && p.Term == "" {
+				newRow.Term = ""
+				before := takeRowImage(p.Row)
+				p.Term = app.Mode.DefaultTerm
+				app.recordRowChange(p.Row, before)
+			}
+			inserted = p.InsertAfter(&newRow)
+		}
+		app.recordInsertRow(inserted)
+		lastInserted[c.Row] = inserted.element
+	}
+	for _, c := range patch {
+		if c.Op == "delete" {
+			head := app.Front()
+			if p := app.rowPtrOf(elements[c.Row]); p != nil {
+				app.removeCurrentRow(&head, &p)
+			}
+		}
+	}
+	if len(patch) > 0 {
+		app.setHardDirty()
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package csvi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nyaosorg/go-readline-ny/keys"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+var errNoProblems = errors.New("No problems in the rows read so far")
+
+// problem is a cell which is not well-formed CSV (see uncsv.Row.Diagnose).
+type problem struct {
+	uncsv.Problem
+	lnum int
+}
+
+func (p problem) String() string {
+	return fmt.Sprintf("row %d, col %d: %v", p.lnum+1, p.Field+1, p.Err)
+}
+
+// problemCache is the problems collected last. It is dropped when cells are
+// edited (see record) and is not used after rows are read, inserted or removed,
+// or the filter or the header is changed, so that the rows are not checked
+// and read again (see Config.Lazy) on each `ge`.
+type problemCache struct {
+	problems    []problem
+	mods        int
+	filter      *rowFilter
+	headerLines int
+}
+
+func (app *Application) invalidateProblems() {
+	app.problemCache = nil
+}
+
+// collectProblems checks the rows read so far except the rows hidden by the filter.
+// The number of the cells of each row is compared with the first row.
+func (app *Application) collectProblems() []problem {
+	if c := app.problemCache; c != nil && c.mods == app.csvLines.Mods() &&
+		c.filter == app.filter && c.headerLines == app.HeaderLines {
+		return c.problems
+	}
+	var problems []problem
+	fields := 0
+	app.visitRows(0, func(lnum int, row *uncsv.Row) bool {
+		if fields == 0 {
+			fields = len(row.Cell)
+		}
+		if app.isGhost(row) || !app.isVisibleAt(lnum, row) {
+			return true
+		}
+		for _, d := range row.Diagnose(app.Mode, fields) {
+			problems = append(problems, problem{Problem: d, lnum: lnum})
+		}
+		return true
+	})
+	app.problemCache = &problemCache{
+		problems:    problems,
+		mods:        app.csvLines.Mods(),
+		filter:      app.filter,
+		headerLines: app.HeaderLines,
+	}
+	return problems
+}
+
+func (app *Application) jumpToProblem(p problem) {
+	app.rememberJump()
+	app.gotoCell(p.lnum, p.Field)
+}
+
+// nextProblem moves to the problem after the cursor,
+// or before it when backward is true, and returns the message of it.
+func (app *Application) nextProblem(backward bool) (string, error) {
+	problems := app.collectProblems()
+	if len(problems) <= 0 {
+		return "", errNoProblems
+	}
+	lnum, col := app.cursorRow.Index(), app.cursorCol
+	found := -1
+	for i, p := range problems {
+		if backward {
+			if p.lnum < lnum || (p.lnum == lnum && p.Field < col) {
+				found = i
+			}
+		} else if p.lnum > lnum || (p.lnum == lnum && p.Field > col) {
+			found = i
+			break
+		}
+	}
+	if found < 0 {
+		return "", errors.New("No more problems")
+	}
+	app.jumpToProblem(problems[found])
+	return fmt.Sprintf("(%d of %d) %s", found+1, len(problems), problems[found]), nil
+}
+
+// cmdProblems shows the problems of the rows read so far.
+// j/k selects one, Enter jumps to it and q closes the list.
+func (app *Application) cmdProblems() string {
+	defer app.clearCache()
+	problems := app.collectProblems()
+	if len(problems) <= 0 {
+		return errNoProblems.Error()
+	}
+	index := 0
+	top := 0
+	for {
+		height := app.lfCount - 1
+		if height < 1 {
+			height = 1
+		}
+		if index < top {
+			top = index
+		} else if index >= top+height {
+			top = index - height + 1
+		}
+		lines := []string{fmt.Sprintf("%d problems (j/k:select, Enter:jump, q:close)", len(problems))}
+		for i := top; i < len(problems) && i < top+height; i++ {
+			mark := "  "
+			if i == index {
+				mark = "> "
+			}
+			lines = append(lines, mark+problems[i].String())
+		}
+		app.drawPanel(lines)
+		ch, err := app.MessageAndGetKey("Select a problem")
+		if err != nil {
+			return err.Error()
+		}
+		switch ch {
+		case "j", keys.Down, keys.CtrlN:
+			if index+1 < len(problems) {
+				index++
+			}
+		case "k", keys.Up, keys.CtrlP:
+			if index > 0 {
+				index--
+			}
+		case keys.Enter:
+			app.jumpToProblem(problems[index])
+			return problems[index].String()
+		case "q", keys.Escape:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package csvi_test
+
+import (
+	"testing"
+)
+
+func TestProblems(t *testing.T) {
+	source := "a,b,c\np,q\"r\"s,t\n1,2\n\"t\"u,v,w\n"
+	// the bare quote, the missing field and the extra character after the quote
+	testCase(t, source, "g|e|r|X|g|e|r|Y|g|e|r|Z|g|E|r|W",
+		"a,b,c\np,X,t\n1,W\n\"Z\",v,w\n")
+	// the problem fixed is not found, but it is found again after undo
+	testCase(t, source, "g|e|r|X|<|g|e|r|Y",
+		"a,b,c\np,X,t\n1,Y\n\"t\"u,v,w\n")
+	testCase(t, source, "g|e|r|X|<|g|e|u|<|g|e|r|Y",
+		"a,b,c\np,Y,t\n1,2\n\"t\"u,v,w\n")
+	// no more problems after the last one
+	testCase(t, source, "g|e|g|e|g|e|g|e|r|X",
+		"a,b,c\np,q\"r\"s,t\n1,2\n\"X\",v,w\n")
+	// Enter jumps to the problem selected in the list
+	testCase(t, source, "E|j|j|\r|r|X", "a,b,c\np,q\"r\"s,t\n1,2\n\"X\",v,w\n")
+	testCase(t, source, ":|problems|j|\r|r|X", "a,b,c\np,q\"r\"s,t\n1,X\n\"t\"u,v,w\n")
+}
@@ -1,16 +1,109 @@
 package csvi
 
 import (
-	"container/list"
-
+	"github.com/hymkor/csvi/internal/indexlist"
 	"github.com/hymkor/csvi/uncsv"
 )
 
+type rowElement = indexlist.Element[*uncsv.Row]
+
+// rowList is the list of the rows. With Config.Lazy, an element with the weight n
+// is the run of the n rows in the file from the row of the element
+// (see uncsv.Row.Following), so that the rows not used are not made.
+// RowPtr always points an element of one row taken out of the run.
+type rowList struct {
+	*indexlist.List[*uncsv.Row]
+	// growing is the run at the end which the rows read next are appended to.
+	growing *rowElement
+	// onSplit is called with the row which starts the element taken out of
+	// the run of head, offset rows after head.
+	onSplit func(head, row *uncsv.Row, offset int)
+	// splits is the number of the changes of the list by split,
+	// which do not change the line numbers.
+	splits int
+}
+
+func newRowList() *rowList {
+	return &rowList{List: indexlist.New[*uncsv.Row]()}
+}
+
+func (L *rowList) Init() {
+	L.List.Init()
+	L.growing = nil
+}
+
+// Mods is indexlist.List.Mods except the changes by split.
+func (L *rowList) Mods() int {
+	return L.List.Mods() - L.splits
+}
+
+// extend appends the row to the run at the end when it follows the run in the file,
+// and reports whether it is appended.
+func (L *rowList) extend(row *uncsv.Row) bool {
+	e := L.growing
+	if e == nil || e != L.Back() || !row.Follows(e.Value, e.Weight()) {
+		return false
+	}
+	L.SetWeight(e, e.Weight()+1)
+	return true
+}
+
+// split takes the row offset rows after the first row of the run e
+// out as an element of one row and returns it.
+func (L *rowList) split(e *rowElement, offset int) *rowElement {
+	w := e.Weight()
+	head := e.Value
+	var rest *rowElement
+	if n := w - offset - 1; n > 0 {
+		rest = &rowElement{Value: head.Following(offset + 1)}
+		L.SetWeight(rest, n)
+	}
+	if e == L.growing {
+		// the element of one row must not grow
+		L.growing = rest
+	}
+	if w <= 1 {
+		return e
+	}
+	mods := L.List.Mods()
+	defer func() { L.splits += L.List.Mods() - mods }()
+	if offset > 0 {
+		L.SetWeight(e, offset)
+		e = L.InsertElementAfter(&rowElement{Value: head.Following(offset)}, e)
+		if L.onSplit != nil {
+			L.onSplit(head, e.Value, offset)
+		}
+	} else {
+		L.SetWeight(e, 1)
+	}
+	if rest != nil {
+		L.InsertElementAfter(rest, e)
+		if L.onSplit != nil {
+			L.onSplit(head, rest.Value, offset+1)
+		}
+	}
+	return e
+}
+
 type RowPtr struct {
 	*uncsv.Row
-	element *list.Element
+	element *rowElement
 	lnum    int
-	list    *list.List
+	// mods is list.Mods() when lnum is known to be right.
+	// The line number is counted again after rows are inserted or removed.
+	mods int
+	list *rowList
+}
+
+// unknownMods is RowPtr.mods for lnum not counted yet.
+const unknownMods = -1
+
+// newRowPtr returns the pointer of the element
+// with the cells of the row unloaded read again (see Config.Lazy).
+func newRowPtr(e *rowElement, lnum, mods int, L *rowList) *RowPtr {
+	row := e.Value
+	row.Load()
+	return &RowPtr{Row: row, element: e, lnum: lnum, mods: mods, list: L}
 }
 
 func (r *RowPtr) Next() *RowPtr {
@@ -18,7 +111,7 @@ func (r *RowPtr) Next() *RowPtr {
 	if next == nil {
 		return nil
 	}
-	return &RowPtr{Row: next.Value.(*uncsv.Row), element: next, lnum: r.lnum + 1, list: r.list}
+	return newRowPtr(r.list.split(next, 0), r.lnum+1, r.mods, r.list)
 }
 
 func (r *RowPtr) Prev() *RowPtr {
@@ -26,42 +119,63 @@ func (r *RowPtr) Prev() *RowPtr {
 	if prev == nil {
 		return nil
 	}
-	return &RowPtr{Row: prev.Value.(*uncsv.Row), element: prev, lnum: r.lnum - 1, list: r.list}
+	return newRowPtr(r.list.split(prev, prev.Weight()-1), r.lnum-1, r.mods, r.list)
 }
 
 func (r *RowPtr) Remove() *uncsv.Row {
-	return r.list.Remove(r.element).(*uncsv.Row)
+	r.Index()
+	return r.list.Remove(r.element)
 }
 
 func (r *RowPtr) Clone() *RowPtr {
-	return &RowPtr{Row: r.element.Value.(*uncsv.Row), element: r.element, lnum: r.lnum, list: r.list}
+	return newRowPtr(r.element, r.lnum, r.mods, r.list)
 }
 
-func frontPtr(L *list.List) *RowPtr {
+func frontPtr(L *rowList) *RowPtr {
 	front := L.Front()
 	if front == nil {
 		return nil
 	}
-	return &RowPtr{Row: front.Value.(*uncsv.Row), element: front, lnum: 0, list: L}
+	front = L.split(front, 0)
+	return newRowPtr(front, 0, L.Mods(), L)
 }
 
-func backPtr(L *list.List) *RowPtr {
+func backPtr(L *rowList) *RowPtr {
 	back := L.Back()
-	return &RowPtr{Row: back.Value.(*uncsv.Row), element: back, lnum: L.Len() - 1, list: L}
+	back = L.split(back, back.Weight()-1)
+	return newRowPtr(back, L.Len()-1, L.Mods(), L)
+}
+
+// atPtr returns the pointer of the row at lnum or nil.
+func atPtr(L *rowList, lnum int) *RowPtr {
+	e, offset := L.Locate(lnum)
+	if e == nil {
+		return nil
+	}
+	e = L.split(e, offset)
+	return newRowPtr(e, lnum, L.Mods(), L)
 }
 
 func (r *RowPtr) InsertAfter(val *uncsv.Row) *RowPtr {
 	next := r.list.InsertAfter(val, r.element)
-	return &RowPtr{Row: next.Value.(*uncsv.Row), element: next, lnum: r.lnum + 1, list: r.list}
+	return newRowPtr(next, 0, unknownMods, r.list)
 }
 
 func (r *RowPtr) InsertBefore(val *uncsv.Row) *RowPtr {
 	next := r.list.InsertBefore(val, r.element)
-	r.lnum++
-	return &RowPtr{Row: next.Value.(*uncsv.Row), element: next, lnum: r.lnum - 1, list: r.list}
+	return newRowPtr(next, 0, unknownMods, r.list)
 }
 
+// Index returns the line number counted from 0. It is counted again in O(log n)
+// when rows are inserted or removed anywhere since it was counted.
+// For the row removed, it is the line number where the row was.
 func (r *RowPtr) Index() int {
+	if mods := r.list.Mods(); r.mods != mods {
+		if lnum := r.element.Index(); lnum >= 0 {
+			r.lnum = lnum
+			r.mods = mods
+		}
+	}
 	return r.lnum
 }
 
@@ -111,6 +225,10 @@ func (app *Application) resetSoftDirty() {
 
 type Result struct {
 	*Application
+
+	// Buffers are all the buffers edited by Config.EditBuffers.
+	// Application is the one active at the end.
+	Buffers []*Application
 }
 
 func (app *Application) Write(data []byte) (int, error) {
@@ -131,19 +249,25 @@ func (app *Application) Len() int {
 
 func (app *Application) push(row *uncsv.Row) {
 	app.csvLines.PushBack(row)
+	app.fitCellWidth(row)
 }
 
-func (app *Application) Each(callback func(*uncsv.Row) bool) {
-	for p := app.Front(); p != nil; p = p.Next() {
-		if !callback(p.Row) {
-			break
-		}
+func (app *Application) fitCellWidth(row *uncsv.Row) {
+	if cw := app.CellWidth; cw != nil && cw.Auto && cw.Fit(row.Cell) {
+		app.clearCache()
 	}
 }
 
+func (app *Application) Each(callback func(*uncsv.Row) bool) {
+	app.visitRows(0, func(_ int, row *uncsv.Row) bool {
+		return app.isGhost(row) || callback(row)
+	})
+}
+
 func (app *Application) RemovedRows(callback func(*uncsv.Row) bool) {
-	for _, p := range app.removedRows {
-		if !callback(p) {
+	for _, e := range app.removedRows {
+		e.Value.Load()
+		if !callback(e.Value) {
 			break
 		}
 	}
@@ -0,0 +1,100 @@
+package csvi
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+func TestRowPtrIndex(t *testing.T) {
+	mode := &uncsv.Mode{Comma: ','}
+	app := (&Config{Mode: mode}).newApplication(nil)
+	for i := 0; i < 100; i++ {
+		row := uncsv.NewRowFromStringSlice(mode, []string{fmt.Sprint(i)})
+		app.push(&row)
+	}
+	p := app.seek(50)
+	next := p.Next()
+	newRow := uncsv.NewRow(mode)
+	app.insertElementAt(0, &rowElement{Value: &newRow})
+	if p.Index() != 51 || next.Index() != 52 {
+		t.Fatalf("insert above: expect 51 and 52, but %d and %d", p.Index(), next.Index())
+	}
+	app.seek(10).Remove()
+	app.seek(20).Remove()
+	if p.Index() != 49 || p.Next().Index() != 50 || p.Prev().Index() != 48 {
+		t.Fatalf("remove above: expect 49, but %d", p.Index())
+	}
+	if p.Cell[0].Text() != "50" || app.seek(49).Row != p.Row {
+		t.Fatalf("seek(49) is not the row of p")
+	}
+	p.Remove()
+	if p.Index() != 49 {
+		t.Fatalf("removed: expect 49 where the row was, but %d", p.Index())
+	}
+}
+
+func TestFindRowLazy(t *testing.T) {
+	var data strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&data, "%d,a\n", i)
+	}
+	r := strings.NewReader(data.String())
+	cfg := &Config{Mode: &uncsv.Mode{Comma: ','}}
+	fetch := cfg.lazyFetch(r, r)
+	app := cfg.newApplication(nil)
+	for {
+		row, err := fetch()
+		if !row.IsZero() {
+			app.load(row)
+		}
+		if err != nil {
+			break
+		}
+	}
+	// the rows are kept as a run without making them
+	elements := func() int {
+		n := 0
+		app.scanRows(func(*rowElement) bool { n++; return true })
+		return n
+	}
+	if n := elements(); n != 1 || app.Len() != 100 {
+		t.Fatalf("expect 100 rows in 1 element, but %d in %d", app.Len(), n)
+	}
+	last := app.Back()
+	p := app.rowPtrOf(last.element)
+	if p == nil || p.Index() != 99 || p.Cell[0].Text() != "99" {
+		t.Fatal("the last row is not found")
+	}
+	middle := app.seek(50)
+	if middle.Cell[0].Text() != "50" || middle.Prev().Cell[0].Text() != "49" {
+		t.Fatal("the row in the run is not found")
+	}
+	// 0-48, 49, 50, 51-98 and 99
+	if n := elements(); n != 5 || p.Index() != 99 || middle.Next().Index() != 51 {
+		t.Fatalf("expect 5 elements, but %d", n)
+	}
+	app.Each(func(row *uncsv.Row) bool { return false })
+	// the rows other than the rows found are not read again
+	loaded := 0
+	app.scanRows(func(e *rowElement) bool {
+		if e.Value.IsLoaded() {
+			loaded++
+		}
+		return true
+	})
+	// 0, 49, 50, 51 and 99
+	if loaded != 5 {
+		t.Fatalf("expect 5 rows loaded, but %d", loaded)
+	}
+	text := ""
+	app.visitRows(97, func(_ int, row *uncsv.Row) bool {
+		text += row.Cell[0].Text()
+		return true
+	})
+	if text != "979899" || elements() != 6 {
+		t.Fatalf("visitRows: %q in %d elements", text, elements())
+	}
+}
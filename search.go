@@ -0,0 +1,141 @@
+package csvi
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// searchPattern is the compiled keyword of `/`, `?`, `*` and `#`.
+//
+// The keyword typed in `/` and `?` may start with these options:
+//
+//	\v : the rest is a regular expression
+//	\c : ignore case
+//	\C : match case (default)
+//	\e : match the whole cell
+//	\l : search only in the current column
+type searchPattern struct {
+	source   string
+	whole    bool
+	isRegexp bool
+	column   int // -1 means any column
+	re       *regexp.Regexp
+}
+
+func compileSearchPattern(source, expr string, ignoreCase, whole bool, column int) (*searchPattern, error) {
+	if whole {
+		expr = "^(?:" + expr + ")$"
+	}
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &searchPattern{
+		source: source,
+		whole:  whole,
+		column: column,
+		re:     re,
+	}, nil
+}
+
+func parseSearchPattern(source string, currentCol int) (*searchPattern, error) {
+	isRegexp := false
+	ignoreCase := false
+	whole := false
+	column := -1
+	s := source
+	for len(s) >= 2 && s[0] == '\\' {
+		switch s[1] {
+		case 'v':
+			isRegexp = true
+		case 'c':
+			ignoreCase = true
+		case 'C':
+			ignoreCase = false
+		case 'e':
+			whole = true
+		case 'l':
+			column = currentCol
+		default:
+			goto parsed
+		}
+		s = s[2:]
+	}
+parsed:
+	if !isRegexp {
+		s = regexp.QuoteMeta(s)
+	}
+	p, err := compileSearchPattern(source, s, ignoreCase, whole, column)
+	if err != nil {
+		return nil, err
+	}
+	p.isRegexp = isRegexp
+	return p, nil
+}
+
+// newExactPattern is used by `*` and `#` to find cells equal to text ignoring case.
+func newExactPattern(text string) *searchPattern {
+	p, _ := compileSearchPattern(text, regexp.QuoteMeta(text), true, true, -1)
+	return p
+}
+
+func (p *searchPattern) matchCell(col int, text string) bool {
+	if p.column >= 0 && p.column != col {
+		return false
+	}
+	return p.re.MatchString(text)
+}
+
+// highlight returns the ranges of shown to be highlighted.
+// shown is the text of the cell as it is displayed on the screen.
+// The pattern is matched with text, and the ranges are mapped onto shown.
+func (p *searchPattern) highlight(col int, text, shown string) [][]int {
+	if !p.matchCell(col, text) {
+		return nil
+	}
+	if p.whole {
+		return [][]int{{0, len(shown)}}
+	}
+	offsets := shownOffsets(text, shown)
+	var result [][]int
+	for _, r := range p.re.FindAllStringIndex(text, -1) {
+		if start, end := offsets[r[0]], offsets[r[1]]; start < end {
+			result = append(result, []int{start, end})
+		}
+	}
+	return result
+}
+
+// shownOffsets returns the offsets in shown for the byte offsets of text,
+// where shown is text with replaceTable and truncate (see drawLine).
+// The offsets of the part of text cut off are the end of shown,
+// so that a match over the cut is highlighted with the tail.
+func shownOffsets(text, shown string) []int {
+	offsets := make([]int, len(text)+1)
+	j := 0
+	for i := 0; i < len(text); {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		piece := replaceTable.Replace(text[i : i+size])
+		if piece == voicedSoundMark || piece == semiVoicedSoundMark {
+			piece = delChar + piece
+		}
+		if !strings.HasPrefix(shown[j:], piece) {
+			j = len(shown)
+			for ; i < len(text); i++ {
+				offsets[i] = j
+			}
+			break
+		}
+		for k := 0; k < size; k++ {
+			offsets[i+k] = j
+		}
+		i += size
+		j += len(piece)
+	}
+	offsets[len(text)] = j
+	return offsets
+}
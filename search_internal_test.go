@@ -0,0 +1,64 @@
+package csvi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+func TestHighlight(t *testing.T) {
+	mode := &uncsv.Mode{Comma: ','}
+	row := uncsv.NewRowFromStringSlice(mode, []string{"banana", "apple"})
+
+	pattern, err := parseSearchPattern(`\van`, -1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	style := lineStyle{
+		cellWidth:   func(int) int { return 10 },
+		screenWidth: 80,
+		colorStyle:  &monoChromeStyle,
+		highlight:   pattern.highlight,
+	}
+	var buffer strings.Builder
+	style.drawLine(row.Cell, -1, false, &buffer)
+	result := buffer.String()
+	expect := "b" + highlightColor + "an" + monoChromeStyle.Even.On +
+		highlightColor + "an" + monoChromeStyle.Even.On + "a"
+	if !strings.Contains(result, expect) {
+		t.Fatalf("%q does not contain %q", result, expect)
+	}
+	if strings.Contains(result, "apple"+highlightColor) ||
+		strings.Contains(result, highlightColor+"apple") {
+		t.Fatalf("%q: apple must not be highlighted", result)
+	}
+}
+
+func TestHighlightShown(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		text    string
+		shown   string
+		expect  string
+	}{
+		// the control pictures are longer than the characters replaced
+		{`\van`, "x\nban", "x␊ban", "x␊b[an]"},
+		// the pattern matches with the text, not with the control pictures
+		{`\va\nb`, "a\nb", "a␊b", "[a␊b]"},
+		{"␊", "a\nb", "a␊b", "a␊b"},
+		// the match cut off is not shown, and the match over the cut has the tail
+		{"an", "banana", "ba…", "b[a…]"},
+		{"na", "bxxxna", "bx…", "bx…"},
+		{"ﾞ", "ｶﾞ", "ｶ\x7Fﾞ", "ｶ[\x7Fﾞ]"},
+	} {
+		pattern, err := parseSearchPattern(c.pattern, -1)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		result := decorate(c.shown, pattern.highlight(0, c.text, c.shown), "[", "]")
+		if result != c.expect {
+			t.Fatalf("%q in %q: expect %q, but %q", c.pattern, c.text, c.expect, result)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package csvi_test
+
+import (
+	"testing"
+)
+
+func TestSearchRegexp(t *testing.T) {
+	src := "id,name\n1,apple\n2,banana\n3,cherry"
+	testCase(t, src, `/|\v^b.*a$|r|X`, "id,name\n1,apple\n2,X\n3,cherry")
+	notFound := "X,name\n1,apple\n2,banana\n3,cherry"
+	testCase(t, src, `/|^b|r|X`, notFound)
+	testCase(t, src, `/|\v[|r|X`, notFound)
+}
+
+func TestSearchIgnoreCase(t *testing.T) {
+	src := "id,name\n1,Apple\n2,APPLE"
+	testCase(t, src, `/|APPLE|r|X`, "id,name\n1,Apple\n2,X")
+	testCase(t, src, `/|\cAPPLE|r|X`, "id,name\n1,X\n2,APPLE")
+	testCase(t, src, `/|\c\CAPPLE|r|X`, "id,name\n1,Apple\n2,X")
+}
+
+func TestSearchWholeCell(t *testing.T) {
+	src := "id,name\n1,pineapple\n2,apple"
+	testCase(t, src, `/|\eapple|r|X`, "id,name\n1,pineapple\n2,X")
+	testCase(t, src, `/|apple|r|X`, "id,name\n1,X\n2,apple")
+}
+
+func TestSearchCurrentColumn(t *testing.T) {
+	src := "a,b\nx,1\n1,y\n2,1"
+	testCase(t, src, `l|/|\l1|r|X`, "a,b\nx,X\n1,y\n2,1")
+	testCase(t, src, `l|/|\l1|n|r|X`, "a,b\nx,1\n1,y\n2,X")
+	testCase(t, src, `/|\l1|r|X`, "a,b\nx,1\nX,y\n2,1")
+}
+
+func TestSearchRepeatLastPattern(t *testing.T) {
+	src := "a,b\nx,1\n1,y\n2,1"
+	testCase(t, src, `/|1|/||r|X`, "a,b\nx,1\nX,y\n2,1")
+}
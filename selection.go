@@ -0,0 +1,248 @@
+package csvi
+
+import (
+	"errors"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+type selectionMode int
+
+const (
+	selectCells selectionMode = iota // `v` and `Ctrl-V`: a rectangular block of cells
+	selectRows                       // `V`: whole rows
+)
+
+// selection is the range between the anchor and the cursor.
+type selection struct {
+	mode selectionMode
+	lnum int
+	col  int
+}
+
+func (app *Application) toggleSelection(mode selectionMode) {
+	if app.selection == nil {
+		app.selection = &selection{
+			mode: mode,
+			lnum: app.cursorRow.Index(),
+			col:  app.cursorCol,
+		}
+	} else if app.selection.mode != mode {
+		app.selection.mode = mode
+	} else {
+		app.selection = nil
+	}
+}
+
+func (app *Application) selectionRange() (top, bottom, left, right int) {
+	top, bottom = app.selection.lnum, app.cursorRow.Index()
+	if top > bottom {
+		top, bottom = bottom, top
+	}
+	left, right = app.selection.col, app.cursorCol
+	if left > right {
+		left, right = right, left
+	}
+	return
+}
+
+func (app *Application) isSelected(lnum, col int) bool {
+	if app.selection == nil {
+		return false
+	}
+	top, bottom, left, right := app.selectionRange()
+	if lnum < top || lnum > bottom {
+		return false
+	}
+	return app.selection.mode == selectRows || (left <= col && col <= right)
+}
+
+// selectedRows returns the rows in the selection which are not hidden by the filter.
+func (app *Application) selectedRows() []*RowPtr {
+	top, bottom, _, _ := app.selectionRange()
+	var rows []*RowPtr
+	for p := app.seek(top); p != nil && p.Index() <= bottom; p = p.Next() {
+		if app.isVisible(p) {
+			rows = append(rows, p)
+		}
+	}
+	return rows
+}
+
+func (app *Application) makeBlockPaster(block [][]uncsv.Cell) pasteFunc {
+	return func(head, dst **RowPtr, col *int, pt pasteType) error {
+		// check all the rows before changing anything
+		// so that the block is never pasted partially.
+		// The rows lacking after the last row are appended.
+		var rows []*RowPtr
+		for p := *dst; p != nil && len(rows) < len(block); p = app.nextOrFetch(p) {
+			var m string
+			if pt == pasteOver {
+				m = app.checkWriteProtect(p)
+			} else {
+				m = app.checkWriteProtectAndColumn(p)
+			}
+			if m != "" {
+				return errors.New(m)
+			}
+			rows = append(rows, p)
+		}
+		if pt == pasteAfter {
+			(*col)++
+		}
+		for len(rows) > 0 && len(rows) < len(block) {
+			rows = append(rows, app.appendEmptyRow(rows[len(rows)-1]))
+		}
+		for i, p := range rows {
+			before := takeRowImage(p.Row)
+			for j, c := range block[i] {
+				pos := *col + j
+				if pt != pasteOver {
+					for pos > len(p.Cell) {
+						p.Insert(len(p.Cell), "", app.Mode)
+					}
+					var newCell uncsv.Cell
+					newCell.SetSource(c.Source(), app.Mode)
+					p.InsertCell(pos, newCell, app.Mode)
+					continue
+				}
+				if pos >= len(p.Cell) {
+					if app.FixColumn {
+						break
+					}
+					for pos >= len(p.Cell) {
+						p.Insert(len(p.Cell), "", app.Mode)
+					}
+				}
+				p.Cell[pos].SetSource(c.Source(), app.Mode)
+			}
+			app.recordRowChange(p.Row, before)
+		}
+		return nil
+	}
+}
+
+// appendEmptyRow inserts an empty row after p as an undoable change
+// and returns it. With Config.FixColumn, it has as many cells as p.
+func (app *Application) appendEmptyRow(p *RowPtr) *RowPtr {
+	newRow := uncsv.NewRow(app.Mode)
+	newRow.Term = p.Term
+	if p.Term == "" {
+		before := takeRowImage(p.Row)
+		p.Term = app.Mode.DefaultTerm
+		app.recordRowChange(p.Row, before)
+	}
+	if app.FixColumn {
+		for len(newRow.Cell) < len(p.Cell) {
+			newRow.Insert(0, "", app.Mode)
+		}
+	}
+	next := p.InsertAfter(&newRow)
+	app.recordInsertRow(next)
+	return next
+}
+
+// yankSelection returns the pasteFunc for the selected rows or block.
+func (app *Application) yankSelection() pasteFunc {
+	rows := app.selectedRows()
+	if app.selection.mode == selectRows {
+		dups := make([]*uncsv.Row, 0, len(rows))
+		for _, p := range rows {
+			dup := &uncsv.Row{Term: p.Term}
+			for _, c := range p.Cell {
+				dup.Cell = append(dup.Cell, c.Clone())
+			}
+			dups = append(dups, dup)
+		}
+		return app.makeRowPaster(dups...)
+	}
+	_, _, left, right := app.selectionRange()
+	block := make([][]uncsv.Cell, 0, len(rows))
+	for _, p := range rows {
+		cells := make([]uncsv.Cell, 0, right-left+1)
+		for c := left; c <= right; c++ {
+			if c < len(p.Cell) {
+				cells = append(cells, p.Cell[c].Clone())
+			} else {
+				cells = append(cells, uncsv.Cell{})
+			}
+		}
+		block = append(block, cells)
+	}
+	return app.makeBlockPaster(block)
+}
+
+// removeSelection deletes the selected rows or block
+// and returns the pasteFunc to put them back.
+func (app *Application) removeSelection() (pasteFunc, error) {
+	rows := app.selectedRows()
+	for _, p := range rows {
+		var m string
+		if app.selection.mode == selectRows {
+			m = app.checkWriteProtect(p)
+		} else {
+			m = app.checkWriteProtectAndColumn(p)
+		}
+		if m != "" {
+			return nil, errors.New(m)
+		}
+	}
+	paste := app.yankSelection()
+	if app.selection.mode == selectRows {
+		// remove from the bottom so that the rows above keep their positions.
+		for i := len(rows) - 1; i >= 0; i-- {
+			app.cursorRow = rows[i]
+			app.removeCurrentRow(&app.startRow, &app.cursorRow)
+		}
+		return paste, nil
+	}
+	_, _, left, right := app.selectionRange()
+	for _, p := range rows {
+		if left >= len(p.Cell) {
+			continue
+		}
+		before := takeRowImage(p.Row)
+		end := right + 1
+		if end > len(p.Cell) {
+			end = len(p.Cell)
+		}
+		p.Cell = append(p.Cell[:left], p.Cell[end:]...)
+		if len(p.Cell) <= 0 {
+			p.Insert(0, "", app.Mode)
+		}
+		app.recordRowChange(p.Row, before)
+	}
+	app.cursorRow = app.seek(rows[0].Index())
+	app.cursorCol = left
+	return paste, nil
+}
+
+// clearSelection empties the text of the selected cells.
+func (app *Application) clearSelection() error {
+	rows := app.selectedRows()
+	for _, p := range rows {
+		if m := app.checkWriteProtect(p); m != "" {
+			return errors.New(m)
+		}
+	}
+	_, _, left, right := app.selectionRange()
+	for _, p := range rows {
+		from, to := left, right
+		if app.selection.mode == selectRows {
+			from, to = 0, len(p.Cell)-1
+		}
+		before := takeRowImage(p.Row)
+		for c := from; c <= to && c < len(p.Cell); c++ {
+			cell := &p.Cell[c]
+			modifiedBefore := cell.Modified()
+			q := cell.IsQuoted()
+			p.Replace(c, "", app.Mode)
+			if q {
+				*cell = cell.Quote(app.Mode)
+			}
+			app.updateSoftDirty(modifiedBefore, cell.Modified())
+		}
+		app.recordRowChange(p.Row, before)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package csvi_test
+
+import (
+	"testing"
+)
+
+func TestSelectBlockYankPaste(t *testing.T) { // `v`, `y` and `p`
+	src := "a,b,c\nd,e,f\ng,h,i"
+	op := "v|j|l|y|G|0|p"
+	exp := "a,b,c\nd,e,f\ng,a,b,h,i\n,d,e" // the rows after the end are appended
+
+	testCase(t, src, op, exp)
+	testCase(t, src, op, src, "-fixcol")   // can not update
+	testCase(t, src, op, src, "-readonly") // can not update
+	testCase(t, src, op+"|u", src)
+
+	op = "v|j|l|y|j|l|\x1Bp"
+	exp = "a,b,c\nd,a,b\ng,d,e"
+	testCase(t, src, op, exp)
+	testCase(t, src, op, exp, "-fixcol")
+
+	op = "v|j|l|y|j|j|l|\x1Bp"
+	exp = "a,b,c\nd,e,f\ng,a,b\n,d,e"
+	testCase(t, src, op, exp, "-fixcol") // the row has as many cells as the last row
+	testCase(t, src+"\n", op, exp+"\n", "-fixcol")
+	testCase(t, src, op, src, "-readonly")
+
+	op = "\x16|j|l|y|l|l|\x1Bp"
+	exp = "a,b,a,b\nd,e,d,e\ng,h,i"
+	testCase(t, src, op, exp)
+	testCase(t, src, op, "a,b,a\nd,e,d\ng,h,i", "-fixcol") // can not add columns
+}
+
+func TestSelectBlockProtectHeader(t *testing.T) {
+	src := "a,b,c\nd,e,f\ng,h,i"
+	op := "j|v|j|l|y|<|\x1Bp"
+	testCase(t, src, op, src, "-p")
+	testCase(t, src, op, "d,e,c\ng,h,f\ng,h,i")
+}
+
+func TestSelectBlockDelete(t *testing.T) { // `v` and `d`
+	src := "a,b,c\nd,e,f\ng,h,i"
+	op := "l|v|j|l|d"
+	exp := "a\nd\ng,h,i"
+
+	testCase(t, src, op, exp)
+	testCase(t, src, op, src, "-fixcol")   // can not update
+	testCase(t, src, op, src, "-readonly") // can not update
+
+	testCase(t, src, op+"|G|0|p", "a\nd\ng,b,c,h,i\n,e,f")
+	testCase(t, src, op+"|u", src)
+}
+
+func TestSelectBlockClear(t *testing.T) { // `v` and `x`
+	src := "a,b,c\nd,e,f\ng,h,i"
+	op := "l|v|j|x"
+	exp := "a,,c\nd,,f\ng,h,i"
+
+	testCase(t, src, op, exp)
+	testCase(t, src, op, exp, "-fixcol")
+	testCase(t, src, op, src, "-readonly") // can not update
+}
+
+func TestSelectRows(t *testing.T) { // `V`
+	src := "a,b,c\nd,e,f\ng,h,i"
+
+	testCase(t, src, "V|j|y|G|p", "a,b,c\nd,e,f\ng,h,i\na,b,c\nd,e,f\n")
+	testCase(t, src, "V|j|y|G|P", "a,b,c\nd,e,f\na,b,c\nd,e,f\ng,h,i")
+	testCase(t, src, "j|V|j|d", "a,b,c")
+	testCase(t, src, "j|V|j|d|<|P", "d,e,f\ng,h,i\na,b,c")
+	testCase(t, src, "j|V|j|d|u", src)
+	testCase(t, src, "V|j|x", ",,\n,,\ng,h,i")
+	testCase(t, src, "V|j|d", src, "-readonly")
+	testCase(t, src, "V|j|d", src, "-p")
+	testCase(t, src, "V|V|j|d|d", "a,b,c\ng,h,i")    // `V` again cancels
+	testCase(t, src, "V|\x1B|j|d|d", "a,b,c\ng,h,i") // ESC cancels
+}
@@ -0,0 +1,324 @@
+package csvi
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+type sortType int
+
+const (
+	sortLexical sortType = iota
+	sortNumeric
+	sortDate
+	sortNatural
+)
+
+type sortKey struct {
+	col        int
+	typ        sortType
+	descending bool
+}
+
+// sortValue is the value of a cell converted for a sort key.
+// ok is false when the text could not be converted.
+type sortValue struct {
+	text string
+	num  float64
+	time time.Time
+	ok   bool
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	"2006/1/2 15:04:05",
+	"2006/1/2 15:04",
+	"2006/1/2",
+	"2006.01.02",
+	"01/02/2006",
+	"02-Jan-2006",
+	"Jan 2, 2006",
+	"2 Jan 2006",
+	time.RFC1123,
+	time.RFC1123Z,
+}
+
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseNumber converts text to a number allowing spaces and thousands separators.
+func parseNumber(text string) (float64, bool) {
+	s := strings.TrimSpace(text)
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n, true
+	}
+	if n, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64); err == nil && s != "" {
+		return n, true
+	}
+	return 0, false
+}
+
+func (key sortKey) valueOf(row *uncsv.Row) sortValue {
+	var text string
+	if key.col < len(row.Cell) {
+		text = row.Cell[key.col].Text()
+	}
+	v := sortValue{text: text, ok: true}
+	switch key.typ {
+	case sortNumeric:
+		v.num, v.ok = parseNumber(text)
+	case sortDate:
+		v.time, v.ok = parseDate(strings.TrimSpace(text))
+	}
+	return v
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// compareNatural compares strings treating each run of digits as a number,
+// so that "file2" comes before "file10".
+func compareNatural(a, b string) int {
+	for len(a) > 0 && len(b) > 0 {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			i := 0
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			j := 0
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			na := strings.TrimLeft(a[:i], "0")
+			nb := strings.TrimLeft(b[:j], "0")
+			if len(na) != len(nb) {
+				if len(na) < len(nb) {
+					return -1
+				}
+				return 1
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			a = a[i:]
+			b = b[j:]
+			continue
+		}
+		if a[0] != b[0] {
+			if a[0] < b[0] {
+				return -1
+			}
+			return 1
+		}
+		a = a[1:]
+		b = b[1:]
+	}
+	return len(a) - len(b)
+}
+
+func (key sortKey) compare(a, b sortValue) int {
+	// Values which could not be converted always come last.
+	if a.ok != b.ok {
+		if a.ok {
+			return -1
+		}
+		return 1
+	}
+	var c int
+	if a.ok {
+		switch key.typ {
+		case sortNumeric:
+			if a.num < b.num {
+				c = -1
+			} else if a.num > b.num {
+				c = 1
+			}
+		case sortDate:
+			c = a.time.Compare(b.time)
+		case sortNatural:
+			c = compareNatural(a.text, b.text)
+		default:
+			c = strings.Compare(a.text, b.text)
+		}
+	} else {
+		c = strings.Compare(a.text, b.text)
+	}
+	if key.descending {
+		c = -c
+	}
+	return c
+}
+
+// reorderBody replaces the rows after the header lines with the elements of the rows,
+// so that the marks to them follow the rows.
+func (app *Application) reorderBody(elements []*rowElement) {
+	if app.HeaderLines < app.Len() {
+		for p := app.seek(app.HeaderLines); p != nil; {
+			next := p.Next()
+			p.Remove()
+			p = next
+		}
+	}
+	for _, e := range elements {
+		app.csvLines.PushBackElement(e)
+	}
+}
+
+func (app *Application) recordReorderBody(before, after []*rowElement) {
+	app.record(
+		func() { app.reorderBody(before) },
+		func() { app.reorderBody(after) })
+}
+
+var errSortInterrupted = errors.New("Sort interrupted")
+
+func (app *Application) sortRows(ctx context.Context, keys []sortKey) error {
+	app.fetchAll(ctx)
+	if ctx.Err() != nil {
+		return errSortInterrupted
+	}
+	if app.Len() <= app.HeaderLines {
+		return nil
+	}
+	type item struct {
+		element *rowElement
+		values  []sortValue
+	}
+	items := make([]item, 0, app.Len()-app.HeaderLines)
+	before := make([]*rowElement, 0, app.Len()-app.HeaderLines)
+	for p := app.seek(app.HeaderLines); p != nil; p = p.Next() {
+		if len(items)%4096 == 0 && ctx.Err() != nil {
+			return errSortInterrupted
+		}
+		values := make([]sortValue, len(keys))
+		for i, key := range keys {
+			values[i] = key.valueOf(p.Row)
+		}
+		items = append(items, item{element: p.element, values: values})
+		before = append(before, p.element)
+	}
+	count := 0
+	canceled := false
+	sort.SliceStable(items, func(i, j int) bool {
+		if canceled {
+			return false
+		}
+		if count++; count%4096 == 0 && ctx.Err() != nil {
+			canceled = true
+			return false
+		}
+		for k, key := range keys {
+			if c := key.compare(items[i].values[k], items[j].values[k]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	if canceled {
+		return errSortInterrupted
+	}
+	after := make([]*rowElement, len(items))
+	for i, it := range items {
+		after[i] = it.element
+	}
+	oldLast := before[len(before)-1].Value
+	newLast := after[len(after)-1].Value
+
+	app.reorderBody(after)
+	app.recordReorderBody(before, after)
+
+	// The row without the line terminator must stay at the end of file.
+	if oldLast != newLast && oldLast.Term == "" {
+		oldLastImage := takeRowImage(oldLast)
+		newLastImage := takeRowImage(newLast)
+		oldLast.Term = newLast.Term
+		newLast.Term = ""
+		app.recordRowChange(newLast, newLastImage)
+		app.recordRowChange(oldLast, oldLastImage)
+	}
+	app.setHardDirty()
+	return nil
+}
+
+func (app *Application) cmdSort(keys []sortKey) string {
+	if app.ReadOnly {
+		return msgReadOnly
+	}
+	ctx, cancel := app.withSlowOperation("Sorting...")
+	err := app.sortRows(ctx, keys)
+	cancel()
+	if err != nil {
+		return err.Error()
+	}
+	app.cursorRow = app.seek(app.cursorRow.Index())
+	app.startRow = app.seek(app.startRow.Index())
+	app.clearCache()
+	return ""
+}
+
+var sortTypeKeys = map[string]sortKey{
+	"s": {typ: sortLexical},
+	"S": {typ: sortLexical, descending: true},
+	"n": {typ: sortNumeric},
+	"N": {typ: sortNumeric, descending: true},
+	"d": {typ: sortDate},
+	"D": {typ: sortDate, descending: true},
+	"v": {typ: sortNatural},
+	"V": {typ: sortNatural, descending: true},
+}
+
+const (
+	sortPrompt          = `Sort ? ["s": string, "n": number, "d": date, "v": natural (uppercase: descending), "+": add a secondary key]`
+	secondarySortPrompt = `Secondary key ? ["s": string, "n": number, "d": date, "v": natural (uppercase: descending)]`
+)
+
+// askSortKeys asks the type of the sort key for the current column.
+// When "+" is given, the key is appended to lastKeys as a secondary key.
+func (app *Application) askSortKeys(lastKeys []sortKey) ([]sortKey, error) {
+	ch, err := app.MessageAndGetKey(sortPrompt)
+	if err != nil {
+		return nil, err
+	}
+	secondary := false
+	if ch == "+" {
+		if len(lastKeys) <= 0 {
+			return nil, errors.New("No previous sort keys")
+		}
+		ch, err = app.MessageAndGetKey(secondarySortPrompt)
+		if err != nil {
+			return nil, err
+		}
+		secondary = true
+	}
+	key, ok := sortTypeKeys[ch]
+	if !ok {
+		return nil, errCanceled
+	}
+	key.col = app.cursorCol
+	if secondary {
+		keys := make([]sortKey, 0, len(lastKeys)+1)
+		keys = append(keys, lastKeys...)
+		return append(keys, key), nil
+	}
+	return []sortKey{key}, nil
+}
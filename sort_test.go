@@ -0,0 +1,56 @@
+package csvi_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSortLexical(t *testing.T) {
+	src := "name,size\nbanana,10\napple,9\ncherry,100"
+	testCase(t, src, "S|s", "name,size\napple,9\nbanana,10\ncherry,100")
+	testCase(t, src, "S|S", "name,size\ncherry,100\nbanana,10\napple,9")
+	testCase(t, src, "S|s", "apple,9\nbanana,10\ncherry,100\nname,size", "-h", "0")
+	testCase(t, src, "S|s", src, "-readonly")
+}
+
+func TestSortNumeric(t *testing.T) {
+	src := "name,size\nbanana,10\napple,9\ncherry,100\nmelon,-"
+	testCase(t, src, "l|S|n", "name,size\napple,9\nbanana,10\ncherry,100\nmelon,-")
+	testCase(t, src, "l|S|N", "name,size\ncherry,100\nbanana,10\napple,9\nmelon,-")
+	testCase(t, src, "l|S|s", "name,size\nmelon,-\nbanana,10\ncherry,100\napple,9")
+}
+
+func TestSortDate(t *testing.T) {
+	src := "d\n2024/3/1\n2023-12-31\n2024-01-15\n"
+	testCase(t, src, "S|d", "d\n2023-12-31\n2024-01-15\n2024/3/1\n")
+	testCase(t, src, "S|D", "d\n2024/3/1\n2024-01-15\n2023-12-31\n")
+}
+
+func TestSortNatural(t *testing.T) {
+	src := "f\nfile10\nfile2\nfile1\n"
+	testCase(t, src, "S|v", "f\nfile1\nfile2\nfile10\n")
+	testCase(t, src, "S|s", "f\nfile1\nfile10\nfile2\n")
+}
+
+func TestSortSecondaryKey(t *testing.T) {
+	src := "k,v\nb,2\na,2\nc,1\n"
+	testCase(t, src, "l|S|n|0|S|+|S", "k,v\nc,1\nb,2\na,2\n")
+	testCase(t, src, "l|S|n|0|S|+|s", "k,v\nc,1\na,2\nb,2\n")
+}
+
+func TestSortUndo(t *testing.T) {
+	src := "name,size\nbanana,10\napple,9\ncherry,100"
+	testCase(t, src, "S|s|u", src)
+	testCase(t, src, "S|s|u|\x12", "name,size\napple,9\nbanana,10\ncherry,100")
+}
+
+func TestSortBeyondScreen(t *testing.T) {
+	// the rows not read yet are fetched without the empty row at EOF
+	rows := []string{"n\n"}
+	for i := 1; i <= 1000; i++ {
+		rows = append(rows, fmt.Sprintf("%04d\n", i))
+	}
+	src := strings.Join(rows, "")
+	testCase(t, src, "S|s", src)
+}
@@ -0,0 +1,194 @@
+package csvi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// fileStamp is the state of the file as loaded or saved last
+// to find that another program has rewritten it.
+type fileStamp struct {
+	path    string
+	size    int64
+	modTime time.Time
+	// hash is calculated while the file is loaded in the background
+	hash hash.Hash
+	sum  []byte
+}
+
+func newFileStamp(path string, h hash.Hash) *fileStamp {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	return &fileStamp{path: path, size: stat.Size(), modTime: stat.ModTime(), hash: h}
+}
+
+// watchSource returns the reader which also calculates the hash of the data
+// read from Config.SourcePath.
+func (cfg *Config) watchSource(r io.Reader) io.Reader {
+	if cfg.SourcePath == "" {
+		return r
+	}
+	h := sha256.New()
+	if cfg.stamp = newFileStamp(cfg.SourcePath, h); cfg.stamp == nil {
+		return r
+	}
+	return io.TeeReader(r, h)
+}
+
+func hashFile(path string) ([]byte, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, fd); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// changed reports whether the file is rewritten since the stamp was taken.
+// The file only touched is not regarded as changed.
+// It must be called after all the rows are loaded.
+func (s *fileStamp) changed() (bool, error) {
+	stat, err := os.Stat(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if stat.Size() == s.size && stat.ModTime().Equal(s.modTime) {
+		return false, nil
+	}
+	if s.hash != nil {
+		s.sum = s.hash.Sum(nil)
+		s.hash = nil
+	}
+	if stat.Size() != s.size || s.sum == nil {
+		return true, nil
+	}
+	sum, err := hashFile(s.path)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(sum, s.sum) {
+		return true, nil
+	}
+	s.modTime = stat.ModTime()
+	return false, nil
+}
+
+// isSource reports whether fname is the file of the stamp.
+func (s *fileStamp) isSource(fname string) bool {
+	if s == nil {
+		return false
+	}
+	path, err := filepath.Abs(fname)
+	return err == nil && path == s.path
+}
+
+// confirmExternalChange asks what to do when fname has been rewritten
+// by another program since it was loaded or saved last.
+// It returns the name of the file to write, or "" with the message
+// when nothing should be written.
+func (app *Application) confirmExternalChange(fname string) (string, string, error) {
+	if !app.stamp.isSource(fname) {
+		return fname, "", nil
+	}
+	changed, err := app.stamp.changed()
+	if err != nil || !changed {
+		return fname, "", err
+	}
+	ch, err := app.MessageAndGetKey(fmt.Sprintf(
+		`"%s" was changed by another program. ["o": overwrite, "s": save as another file, "r": reload and reapply the changes, other: cancel]`,
+		filepath.Base(fname)))
+	if err != nil {
+		return "", "", err
+	}
+	switch ch {
+	case "o", "O":
+		return fname, "", nil
+	case "s", "S":
+		fname, err := app.GetFilename(app, "write to>", "")
+		if err != nil {
+			return "", "", err
+		}
+		if app.stamp.isSource(fname) {
+			return "", "", errors.New("The same file as the changed one")
+		}
+		return fname, "", nil
+	case "r", "R":
+		message, err := app.reloadAndReapply()
+		return "", message, err
+	}
+	return "", "", errCanceled
+}
+
+// reloadAndReapply reads the file of the stamp again
+// and applies the changes not saved yet to it as one undoable change.
+// Nothing is changed when the rows changed are also changed in the file.
+func (app *Application) reloadAndReapply() (string, error) {
+	changes := app.Changes()
+	fd, err := os.Open(app.stamp.path)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	rows, err := readRows(io.TeeReader(fd, h), app.Mode)
+	fd.Close()
+	if err != nil {
+		return "", err
+	}
+	for i := range changes {
+		if err := changes[i].check(len(rows), func(i int) *uncsv.Row { return rows[i] }); err != nil {
+			return "", fmt.Errorf("Can not reapply the changes: %w", err)
+		}
+	}
+	stamp := newFileStamp(app.stamp.path, nil)
+	if stamp == nil {
+		return "", fmt.Errorf("%s: can not get the state", app.stamp.path)
+	}
+	stamp.sum = h.Sum(nil)
+
+	app.csvLines.Init()
+	app.base = nil
+	app.baseLen = 0
+	app.removedRows = nil
+	for _, row := range rows {
+		app.load(row)
+	}
+	if app.Len() <= 0 {
+		newRow := uncsv.NewRow(app.Mode)
+		app.push(&newRow)
+	}
+	app.selection = nil
+	app.resetDirty()
+	app.resetHistory()
+	app.invalidateFilterCount()
+	app.invalidateGhosts()
+	app.resyncCursor()
+	if err := app.applyPatch(changes); err != nil {
+		return "", err
+	}
+	app.endCommand()
+	app.stamp = stamp
+	return fmt.Sprintf("Reloaded \"%s\" and reapplied %d changes (not saved yet)",
+		filepath.Base(stamp.path), len(changes)), nil
+}
@@ -0,0 +1,73 @@
+package csvi_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hymkor/csvi"
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// testExternalChange edits the file while `:rewrite` replaces it with rewritten
+// like another program.
+func testExternalChange(t *testing.T, path, rewritten, script string) {
+	t.Helper()
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer fd.Close()
+	cfg := &csvi.Config{
+		Mode:       &uncsv.Mode{Comma: ','},
+		Pilot:      &scriptPilot{script: strings.Split(script, "|")},
+		SourcePath: path,
+		Commands: map[string]func(*csvi.CommandEventArgs) (*csvi.CommandResult, error){
+			"rewrite": func(*csvi.CommandEventArgs) (*csvi.CommandResult, error) {
+				if err := os.WriteFile(path, []byte(rewritten), 0666); err != nil {
+					return nil, err
+				}
+				later := time.Now().Add(time.Minute)
+				return &csvi.CommandResult{}, os.Chtimes(path, later, later)
+			},
+		},
+	}
+	if _, err := cfg.Edit(fd, io.Discard); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestExternalChangeReload(t *testing.T) {
+	path := makeSource(t, "a.csv", "a,b\nc,d\n")
+	testExternalChange(t, path, "a,b\nc,d\ne,f\n",
+		"r|X|:|rewrite|w|"+path+"|r|w|"+path+"|y|q")
+	checkResult(t, path, "X,b\nc,d\ne,f\n")
+}
+
+func TestExternalChangeConflict(t *testing.T) {
+	path := makeSource(t, "a.csv", "a,b\nc,d\n")
+	// the row changed also by another program is not reapplied
+	testExternalChange(t, path, "Z,b\nc,d\n",
+		"r|X|:|rewrite|w|"+path+"|r|w|"+path+"|o|y|q")
+	checkResult(t, path, "X,b\nc,d\n")
+}
+
+func TestExternalChangeSaveAs(t *testing.T) {
+	path := makeSource(t, "a.csv", "a,b\n")
+	other := filepath.Join(t.TempDir(), "other.csv")
+	testExternalChange(t, path, "Z,b\n",
+		"r|X|:|rewrite|w|"+path+"|s|"+other+"|q")
+	checkResult(t, path, "Z,b\n")
+	checkResult(t, other, "X,b\n")
+}
+
+func TestExternalChangeTouched(t *testing.T) {
+	path := makeSource(t, "a.csv", "a,b\n")
+	// the file rewritten with the same contents is not regarded as changed
+	testExternalChange(t, path, "a,b\n",
+		"r|X|:|rewrite|w|"+path+"|y|q")
+	checkResult(t, path, "X,b\n")
+}
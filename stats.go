@@ -0,0 +1,186 @@
+package csvi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/hymkor/csvi/internal/ansi"
+	"github.com/hymkor/csvi/uncsv"
+)
+
+const statsTopN = 5
+
+type valueCount struct {
+	value string
+	count int
+}
+
+// columnStats is the summary of the values in a column.
+type columnStats struct {
+	rows     int
+	count    int // the number of non-empty cells
+	empty    int
+	distinct int
+	numbers  int // the number of cells which can be read as a number
+	min      float64
+	max      float64
+	sum      float64
+	mean     float64
+	median   float64
+	top      []valueCount
+	maxWidth int
+}
+
+func newColumnStats(texts []string) *columnStats {
+	st := &columnStats{rows: len(texts)}
+	counts := map[string]int{}
+	var numbers []float64
+	for _, text := range texts {
+		if w := runewidth.StringWidth(replaceTable.Replace(text)); w > st.maxWidth {
+			st.maxWidth = w
+		}
+		if text == "" {
+			st.empty++
+			continue
+		}
+		st.count++
+		counts[text]++
+		if n, ok := parseNumber(text); ok {
+			numbers = append(numbers, n)
+		}
+	}
+	st.distinct = len(counts)
+
+	if st.numbers = len(numbers); st.numbers > 0 {
+		sort.Float64s(numbers)
+		st.min = numbers[0]
+		st.max = numbers[len(numbers)-1]
+		for _, n := range numbers {
+			st.sum += n
+		}
+		st.mean = st.sum / float64(len(numbers))
+		if half := len(numbers) / 2; len(numbers)%2 == 1 {
+			st.median = numbers[half]
+		} else {
+			st.median = (numbers[half-1] + numbers[half]) / 2
+		}
+	}
+
+	for value, count := range counts {
+		st.top = append(st.top, valueCount{value: value, count: count})
+	}
+	sort.Slice(st.top, func(i, j int) bool {
+		if st.top[i].count != st.top[j].count {
+			return st.top[i].count > st.top[j].count
+		}
+		return st.top[i].value < st.top[j].value
+	})
+	if len(st.top) > statsTopN {
+		st.top = st.top[:statsTopN]
+	}
+	return st
+}
+
+func (st *columnStats) lines(title string) []string {
+	unique := ""
+	if st.distinct == st.count && st.empty == 0 {
+		unique = " (unique)"
+	}
+	lines := []string{
+		title,
+		fmt.Sprintf("  rows: %d, non-empty: %d, empty: %d, distinct: %d%s",
+			st.rows, st.count, st.empty, st.distinct, unique),
+	}
+	if st.numbers > 0 {
+		lines = append(lines,
+			fmt.Sprintf("  numbers: %d, min: %g, max: %g, sum: %g, mean: %g, median: %g",
+				st.numbers, st.min, st.max, st.sum, st.mean, st.median))
+	}
+	lines = append(lines, fmt.Sprintf("  max width: %d", st.maxWidth))
+	if len(st.top) > 0 {
+		lines = append(lines, "  most frequent values:")
+		for _, v := range st.top {
+			lines = append(lines, fmt.Sprintf("  %6d  %s", v.count, replaceTable.Replace(v.value)))
+		}
+	}
+	return lines
+}
+
+// drawPanel draws lines over the top of the screen and erases the rest.
+func (app *Application) drawPanel(lines []string) {
+	app.rewind()
+	n := 0
+	for _, line := range lines {
+		if n >= app.lfCount {
+			break
+		}
+		io.WriteString(app.out, truncate(line, app.screenWidth-1, ""))
+		io.WriteString(app.out, ansi.ERASE_LINE)
+		io.WriteString(app.out, "\r\n")
+		n++
+	}
+	for ; n < app.lfCount; n++ {
+		io.WriteString(app.out, ansi.ERASE_LINE)
+		io.WriteString(app.out, "\r\n")
+	}
+}
+
+// showPanel draws lines over the top of the screen until a key is pressed.
+func (app *Application) showPanel(lines []string) {
+	app.drawPanel(lines)
+	app.MessageAndGetKey("Press any key")
+	app.clearCache()
+}
+
+var errStatsInterrupted = errors.New("Statistics interrupted")
+
+func (app *Application) collectColumn(col int) ([]string, error) {
+	ctx, cancel := app.withSlowOperation("Reading all data...")
+	defer cancel()
+	app.fetchAll(ctx)
+	if ctx.Err() != nil {
+		return nil, errStatsInterrupted
+	}
+	var texts []string
+	interrupted := false
+	app.visitRows(app.HeaderLines, func(lnum int, row *uncsv.Row) bool {
+		if len(texts)%4096 == 0 && ctx.Err() != nil {
+			interrupted = true
+			return false
+		}
+		if !app.isVisibleAt(lnum, row) || app.isGhost(row) {
+			return true
+		}
+		if col < len(row.Cell) {
+			texts = append(texts, row.Cell[col].Text())
+		} else {
+			texts = append(texts, "")
+		}
+		return true
+	})
+	if interrupted {
+		return nil, errStatsInterrupted
+	}
+	return texts, nil
+}
+
+func (app *Application) cmdStats() string {
+	col := app.cursorCol
+	texts, err := app.collectColumn(col)
+	if err != nil {
+		return err.Error()
+	}
+	title := fmt.Sprintf("Column %d", col+1)
+	if app.HeaderLines > 0 && col < len(app.Front().Cell) {
+		title = fmt.Sprintf("%s %q", title, app.Front().Cell[col].Text())
+	}
+	if app.filter != nil {
+		title += " (filtered)"
+	}
+	app.showPanel(newColumnStats(texts).lines(title))
+	return ""
+}
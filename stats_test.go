@@ -0,0 +1,36 @@
+package csvi
+
+import (
+	"testing"
+)
+
+func TestColumnStats(t *testing.T) {
+	st := newColumnStats([]string{"3", "1,000", "", "apple", "3", "10"})
+	if st.rows != 6 || st.count != 5 || st.empty != 1 || st.distinct != 4 {
+		t.Fatalf("rows=%d count=%d empty=%d distinct=%d",
+			st.rows, st.count, st.empty, st.distinct)
+	}
+	if st.numbers != 4 || st.min != 3 || st.max != 1000 || st.sum != 1016 {
+		t.Fatalf("numbers=%d min=%g max=%g sum=%g", st.numbers, st.min, st.max, st.sum)
+	}
+	if st.mean != 254 || st.median != 6.5 {
+		t.Fatalf("mean=%g median=%g", st.mean, st.median)
+	}
+	if len(st.top) != 4 || st.top[0] != (valueCount{value: "3", count: 2}) {
+		t.Fatalf("top=%v", st.top)
+	}
+	if st.maxWidth != 5 {
+		t.Fatalf("maxWidth=%d", st.maxWidth)
+	}
+}
+
+func TestColumnStatsUnique(t *testing.T) {
+	st := newColumnStats([]string{"あ", "い", "う"})
+	if st.distinct != st.count || st.empty != 0 || st.numbers != 0 {
+		t.Fatalf("count=%d distinct=%d empty=%d numbers=%d",
+			st.count, st.distinct, st.empty, st.numbers)
+	}
+	if st.maxWidth != 2 {
+		t.Fatalf("maxWidth=%d", st.maxWidth)
+	}
+}
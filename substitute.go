@@ -0,0 +1,177 @@
+package csvi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nyaosorg/go-readline-ny"
+	"github.com/nyaosorg/go-readline-ny/keys"
+)
+
+type substituteScope int
+
+const (
+	substituteCell substituteScope = iota
+	substituteRow
+	substituteColumn
+	substituteAll
+)
+
+// replace returns the text replaced with repl.
+// repl may contain $1, ${name} and so on when the pattern is a regular expression.
+func (p *searchPattern) replace(text, repl string) string {
+	if p.isRegexp {
+		return p.re.ReplaceAllString(text, repl)
+	}
+	return p.re.ReplaceAllLiteralString(text, repl)
+}
+
+// eachSubstituteTarget calls callback with the cells in the scope.
+// When callback returns false, the enumeration stops.
+func (app *Application) eachSubstituteTarget(scope substituteScope, callback func(*RowPtr, int) bool) {
+	switch scope {
+	case substituteCell:
+		callback(app.cursorRow, app.cursorCol)
+	case substituteRow:
+		for c := 0; c < len(app.cursorRow.Cell); c++ {
+			if !callback(app.cursorRow, c) {
+				return
+			}
+		}
+	case substituteColumn:
+		for p := app.frontVisible(); p != nil; p = app.nextVisible(p) {
+			if app.cursorCol < len(p.Cell) && !callback(p, app.cursorCol) {
+				return
+			}
+		}
+	default:
+		for p := app.frontVisible(); p != nil; p = app.nextVisible(p) {
+			for c := 0; c < len(p.Cell); c++ {
+				if !callback(p, c) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (app *Application) askSubstitute() (substituteScope, *searchPattern, string, error) {
+	ch, err := app.MessageAndGetKey(`Substitute in ? ["l"/"v"/SPACE/TAB/C-F/→: cell, "r": row, "|"/"c": column, "%"/"a": all]`)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	var scope substituteScope
+	switch ch {
+	case "l", "v", " ", "\t", keys.CtrlF, keys.Right:
+		scope = substituteCell
+	case "r":
+		scope = substituteRow
+	case "|", "c":
+		scope = substituteColumn
+	case "%", "a":
+		scope = substituteAll
+	default:
+		return 0, nil, "", errCanceled
+	}
+	defaultText := ""
+	if app.searchPattern != nil {
+		defaultText = app.searchPattern.source
+	}
+	source, err := app.Pilot.ReadLine(app.out, "substitute>", defaultText, nil)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	pattern, err := parseSearchPattern(source, app.cursorCol)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	repl, err := app.Pilot.ReadLine(app.out, "with>", "", nil)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	return scope, pattern, repl, nil
+}
+
+func (app *Application) cmdSubstitute() string {
+	if app.ReadOnly {
+		return msgReadOnly
+	}
+	app.clearCache()
+	scope, pattern, repl, err := app.askSubstitute()
+	if err != nil {
+		if errors.Is(err, errCanceled) || errors.Is(err, readline.CtrlC) || errors.Is(err, io.EOF) {
+			return ""
+		}
+		return err.Error()
+	}
+	app.searchPattern = pattern
+
+	if scope == substituteColumn || scope == substituteAll {
+		ctx, cancel := app.withSlowOperation("Reading all data...")
+		app.fetchAll(ctx)
+		ctxErr := ctx.Err()
+		cancel()
+		if ctxErr != nil {
+			return "Substitute interrupted"
+		}
+	}
+	count := 0
+	matched := 0
+	all := false
+	var result error
+	app.eachSubstituteTarget(scope, func(p *RowPtr, col int) bool {
+		text := p.Cell[col].Text()
+		if !pattern.matchCell(col, text) {
+			return true
+		}
+		if app.checkWriteProtect(p) != "" {
+			return true
+		}
+		matched++
+		if !all {
+			app.cursorRow = p.Clone()
+			app.cursorCol = col
+			app.scrollToCursor()
+			app.repaint()
+			ch, err := app.MessageAndGetKey(`Replace ? ["y": yes, "n": no, "a": all, "q": quit]`)
+			if err != nil {
+				result = err
+				return false
+			}
+			switch ch {
+			case "y":
+			case "a":
+				all = true
+			case "n":
+				return true
+			default:
+				return false
+			}
+		}
+		newText, err := app.validate(p, col, pattern.replace(text, repl))
+		if err != nil {
+			result = err
+			return false
+		}
+		before := takeRowImage(p.Row)
+		modifiedBefore := p.Cell[col].Modified()
+		q := p.Cell[col].IsQuoted()
+		p.Replace(col, newText, app.Mode)
+		if q {
+			p.Cell[col] = p.Cell[col].Quote(app.Mode)
+		}
+		app.recordRowChange(p.Row, before)
+		app.updateSoftDirty(modifiedBefore, p.Cell[col].Modified())
+		count++
+		return true
+	})
+	app.clearCache()
+	if result != nil {
+		return result.Error()
+	}
+	if matched <= 0 {
+		return fmt.Sprintf("%s: not found", pattern.source)
+	}
+	return fmt.Sprintf("%d substitution(s)", count)
+}
@@ -0,0 +1,36 @@
+package csvi_test
+
+import (
+	"testing"
+)
+
+func TestSubstituteAll(t *testing.T) {
+	src := "vendor,memo\nAcme Inc,from Acme\nAcmee,Acme"
+	testCase(t, src, "s|%|Acme|ACME|a", "vendor,memo\nACME Inc,from ACME\nACMEe,ACME")
+	testCase(t, src, "s|%|Acme|ACME|y|n|q", "vendor,memo\nACME Inc,from Acme\nAcmee,Acme")
+	testCase(t, src, "s|%|Acme|ACME|n|n|y|y", "vendor,memo\nAcme Inc,from Acme\nACMEe,ACME")
+	testCase(t, src, "s|%|\\eAcme|ACME|a", "vendor,memo\nAcme Inc,from Acme\nAcmee,ACME")
+	testCase(t, src, "s", src, "-readonly")
+}
+
+func TestSubstituteRegexp(t *testing.T) {
+	src := "name\nSmith John\nDoe Jane"
+	testCase(t, src, `s|c|\v(\w+) (\w+)|$2 $1|a`, "name\nJohn Smith\nJane Doe")
+	testCase(t, "First Last\nSmith John", `s|c|\v(\w+) (\w+)|$2 $1|a`, "First Last\nJohn Smith", "-p")
+	testCase(t, src, `s|c|(\w+)|$1`, src)
+}
+
+func TestSubstituteScope(t *testing.T) {
+	src := "a,a\na,a"
+	testCase(t, src, "s|l|a|b|y", "b,a\na,a")
+	testCase(t, src, "s|r|a|b|a", "b,b\na,a")
+	testCase(t, src, "l|s|c|a|b|a", "a,b\na,b")
+}
+
+func TestSubstituteQuote(t *testing.T) {
+	src := "a,b\nx,y"
+	testCase(t, src, "j|s|l|x|1,2|y", "a,b\n\"1,2\",y")
+	testCase(t, src, "j|s|l|x|1,2|y|u", src)
+	// the quotes of a quoted cell are kept
+	testCase(t, "v,m\n\"Acme\",x", "s|%|Acme|ACME|a", "v,m\n\"ACME\",x")
+}
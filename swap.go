@@ -0,0 +1,221 @@
+package csvi
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// swapInterval is the minimum interval of writing the swap file.
+// The changes after it are written by the next command,
+// or when no key is typed until the interval passes.
+const swapInterval = 2 * time.Second
+
+// SwapPath returns the path of the swap file for fname like `.file.csv.csvi-swp`.
+func SwapPath(fname string) string {
+	dir, base := filepath.Split(fname)
+	return filepath.Join(dir, "."+base+".csvi-swp")
+}
+
+// writeSwap writes all the rows to Config.SwapPath without marking them saved.
+// It is written to a temporary file first and renamed
+// so that a crash while writing does not break the previous swap file.
+func (app *Application) writeSwap() error {
+	ctx, cancel := app.withSlowOperation("Writing the swap file...")
+	defer cancel()
+	app.fetchAll(ctx)
+	if ctx.Err() != nil {
+		return errors.New("Writing the swap file interrupted")
+	}
+	fd, err := os.CreateTemp(filepath.Dir(app.SwapPath), ".csvi-swp*")
+	if err != nil {
+		return err
+	}
+	tmpName := fd.Name()
+	err = app.Mode.DumpBy(ctx, app.dumpRows(), fd)
+	if err1 := fd.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(tmpName, app.SwapPath)
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// removeSwap removes the swap file written by the application.
+func (app *Application) removeSwap() {
+	if app.swapWritten {
+		os.Remove(app.SwapPath)
+		app.swapWritten = false
+	}
+}
+
+// updateSwap writes the swap file when the buffer has changed since the last time
+// and removes it when there are no changes not saved.
+// It is not written again within swapInterval unless force is true.
+func (app *Application) updateSwap(force bool) error {
+	if app.SwapPath == "" || app.ReadOnly {
+		return nil
+	}
+	if !app.IsDirty() {
+		app.removeSwap()
+		app.swapChanges = app.history.changes
+		return nil
+	}
+	if app.swapWritten && app.swapChanges == app.history.changes {
+		return nil
+	}
+	if !force && time.Since(app.swapTime) < swapInterval {
+		return nil
+	}
+	if err := app.writeSwap(); err != nil {
+		return fmt.Errorf("swap: %w", err)
+	}
+	app.swapWritten = true
+	app.swapChanges = app.history.changes
+	app.swapTime = time.Now()
+	return nil
+}
+
+// swapDelay returns the time until the changes not written to the swap file yet
+// can be written, or 0 when there are no such changes.
+func (app *Application) swapDelay() time.Duration {
+	if app.SwapPath == "" || app.ReadOnly || !app.IsDirty() {
+		return 0
+	}
+	if app.swapWritten && app.swapChanges == app.history.changes {
+		return 0
+	}
+	if d := swapInterval - time.Since(app.swapTime); d > time.Millisecond {
+		return d
+	}
+	return time.Millisecond
+}
+
+// skipBom skips the BOM which ReadLine does not skip
+// once the mode has detected the encoding in the original file.
+func skipBom(br *bufio.Reader, mode *uncsv.Mode) {
+	bom := "\xEF\xBB\xBF"
+	if mode.IsUTF16LE() {
+		bom = "\xFF\xFE"
+	} else if mode.IsUTF16BE() {
+		bom = "\xFE\xFF"
+	}
+	if b, err := br.Peek(len(bom)); err == nil && string(b) == bom {
+		br.Discard(len(bom))
+	}
+}
+
+// readRows reads all the rows of r with the mode detected already.
+func readRows(r io.Reader, mode *uncsv.Mode) ([]*uncsv.Row, error) {
+	br := bufio.NewReader(r)
+	skipBom(br, mode)
+	var rows []*uncsv.Row
+	for {
+		row, err := uncsv.ReadLine(br, mode)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if !row.IsZero() {
+			rows = append(rows, row)
+		}
+		if err != nil {
+			return rows, nil
+		}
+	}
+}
+
+func (app *Application) readSwap() ([]*uncsv.Row, error) {
+	fd, err := os.Open(app.SwapPath)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return readRows(fd, app.Mode)
+}
+
+// recoverFrom makes the buffer the same as rows.
+// The rows of the file paired with them are kept
+// so that the recovered changes can be reviewed as changes not saved yet.
+func (app *Application) recoverFrom(rows []*uncsv.Row) error {
+	ctx, cancel := app.withSlowOperation("Recovering...")
+	app.fetchAll(ctx)
+	err := ctx.Err()
+	cancel()
+	if err != nil {
+		return errors.New("Recovering interrupted")
+	}
+	if len(rows) <= 0 {
+		return errors.New("The swap file is empty")
+	}
+	current := make([]*uncsv.Row, 0, app.Len())
+	for p := app.Front(); p != nil; p = p.Next() {
+		current = append(current, p.Row)
+	}
+	match, _ := alignByOrder(current, rows)
+	used := make([]bool, len(current))
+	app.csvLines.Init()
+	for j, row := range rows {
+		if i := match[j]; i >= 0 {
+			used[i] = true
+			app.setTexts(current[i], cellTexts(row.Cell))
+			current[i].Term = row.Term
+			row = current[i]
+		}
+		app.push(row)
+	}
+	for i, row := range current {
+		if !used[i] {
+			app.removedRows = append(app.removedRows, &rowElement{Value: row})
+		}
+	}
+	app.setHardDirty()
+	return nil
+}
+
+// checkSwap asks what to do with the swap file left by the previous session.
+// When it is neither recovered nor deleted, it is kept and not updated.
+func (app *Application) checkSwap() error {
+	if app.SwapPath == "" || app.ReadOnly {
+		return nil
+	}
+	stat, err := os.Stat(app.SwapPath)
+	if err != nil {
+		return nil
+	}
+	ch, err := app.MessageAndGetKey(fmt.Sprintf(
+		`Found the swap file "%s" (%s). ["r": recover, "d": delete it, other: keep it and do not write it]`,
+		app.SwapPath, stat.ModTime().Format("2006-01-02 15:04:05")))
+	if err != nil {
+		return err
+	}
+	switch ch {
+	case "r", "R":
+		rows, err := app.readSwap()
+		if err != nil {
+			return err
+		}
+		if err := app.recoverFrom(rows); err != nil {
+			return err
+		}
+		app.startRow = app.Front()
+		app.cursorRow = app.Front()
+		// the swap file is kept until the recovered rows are saved
+		app.swapWritten = true
+	case "d", "D":
+		return os.Remove(app.SwapPath)
+	default:
+		app.SwapPath = ""
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package csvi_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hymkor/csvi"
+	"github.com/hymkor/csvi/uncsv"
+)
+
+func swapSource(t *testing.T, source, swap string) (string, string) {
+	t.Helper()
+	path := makeSource(t, "src.csv", source)
+	swapPath := csvi.SwapPath(path)
+	if swap != "" {
+		if err := os.WriteFile(swapPath, []byte(swap), 0600); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	return path, swapPath
+}
+
+func swapExists(t *testing.T, swapPath string) bool {
+	t.Helper()
+	_, err := os.Stat(swapPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatal(err.Error())
+	}
+	return err == nil
+}
+
+func TestSwapRecover(t *testing.T) {
+	path, swapPath := swapSource(t, "a,b\nc,d\n", "a,b\nX,d\ne,f\n")
+	output := filepath.Join(t.TempDir(), "out.csv")
+
+	testBuffers(t, fmt.Sprintf("r|w|%s|q", output), path)
+	checkResult(t, output, "a,b\nX,d\ne,f\n")
+	checkResult(t, path, "a,b\nc,d\n")
+	if swapExists(t, swapPath) {
+		t.Fatal("the swap file is left after quit")
+	}
+}
+
+func TestSwapDelete(t *testing.T) {
+	path, swapPath := swapSource(t, "a,b\n", "X,b\n")
+	testBuffers(t, "d|q", path)
+	if swapExists(t, swapPath) {
+		t.Fatal("the swap file is not deleted")
+	}
+	checkResult(t, path, "a,b\n")
+}
+
+func TestSwapKeep(t *testing.T) {
+	path, swapPath := swapSource(t, "a,b\n", "X,b\n")
+	// the swap file neither recovered nor deleted is not overwritten
+	testBuffers(t, "k|r|Y|q|n", path)
+	checkResult(t, swapPath, "X,b\n")
+}
+
+func TestSwapWritten(t *testing.T) {
+	path, swapPath := swapSource(t, "\xEF\xBB\xBFa,b\r\nc,d\r\n", "")
+	instance, err := newTestOptions("-auto", "r|X|j|l|r|Y", path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	enable := disableStdout(t)
+	// the script ends without quitting like the terminal is closed
+	err = instance.Run()
+	enable()
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("expect io.EOF, but %v", err)
+	}
+	checkResult(t, swapPath, "\xEF\xBB\xBFX,b\r\nc,Y\r\n")
+
+	output := filepath.Join(t.TempDir(), "out.csv")
+	testBuffers(t, fmt.Sprintf("r|w|%s|q", output), path)
+	checkResult(t, output, "\xEF\xBB\xBFX,b\r\nc,Y\r\n")
+	if swapExists(t, swapPath) {
+		t.Fatal("the swap file is left after the recovered rows are saved")
+	}
+
+	testBuffers(t, "r|Z|q|n", path)
+	if swapExists(t, swapPath) {
+		t.Fatal("the swap file is left after quit without saving")
+	}
+}
+
+func TestNoSwap(t *testing.T) {
+	path, swapPath := swapSource(t, "a,b\n", "")
+	instance, err := newTestOptions("-noswap", "-auto", "r|X", path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	enable := disableStdout(t)
+	instance.Run()
+	enable()
+	if swapExists(t, swapPath) {
+		t.Fatal("the swap file is written with -noswap")
+	}
+}
+
+// idleSwapPilot types the script, and then waits
+// until the swap file has the text expected without typing keys.
+type idleSwapPilot struct {
+	scriptPilot
+	swapPath string
+	expect   string
+	written  bool
+}
+
+func (p *idleSwapPilot) GetKey() (string, error) {
+	if len(p.script) > 0 {
+		return p.scriptPilot.GetKey()
+	}
+	for i := 0; i < 100 && !p.written; i++ {
+		time.Sleep(50 * time.Millisecond)
+		data, err := os.ReadFile(p.swapPath)
+		p.written = err == nil && string(data) == p.expect
+	}
+	return "", io.EOF
+}
+
+func TestSwapIdle(t *testing.T) {
+	path, swapPath := swapSource(t, "a,b\n", "")
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer fd.Close()
+	// the second change within swapInterval is written while no key is typed
+	pilot := &idleSwapPilot{
+		scriptPilot: scriptPilot{script: strings.Split("r|X|l|r|Y", "|")},
+		swapPath:    swapPath,
+		expect:      "X,Y\n",
+	}
+	cfg := &csvi.Config{
+		Mode:     &uncsv.Mode{Comma: ','},
+		Pilot:    pilot,
+		SwapPath: swapPath,
+	}
+	cfg.Edit(fd, io.Discard)
+	if !pilot.written {
+		t.Fatal("the swap file is not written while no key is typed")
+	}
+}
@@ -0,0 +1,113 @@
+package uncsv
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	// ErrBareQuote is the error of a quote in a field not quoted.
+	ErrBareQuote = errors.New("bare \" in non-quoted-field")
+
+	// ErrFieldCount is the error of a row whose number of fields differs from the header.
+	ErrFieldCount = errors.New("wrong number of fields")
+)
+
+// Problem is a field which is not well-formed CSV.
+// ReadLine reads such data without errors: a quote not closed
+// makes the rest of the data one cell.
+type Problem struct {
+	Field int   // the index of the cell
+	Index int   // the byte index in the source of the cell
+	Err   error // ErrQuote, ErrBareQuote or ErrFieldCount
+}
+
+// Diagnose returns the problems of the row: a quoted field which is not closed
+// or which has characters after the closing quote (ErrQuote)
+// and a quote in a field not quoted (ErrBareQuote).
+// When fields is positive and the row does not have fields cells,
+// ErrFieldCount is reported for the first extra cell or the last cell.
+func (row *Row) Diagnose(mode *Mode, fields int) []Problem {
+	var problems []Problem
+	for i, c := range row.Cell {
+		if index, err := diagnoseCell(c.source, mode); err != nil {
+			problems = append(problems, Problem{Field: i, Index: index, Err: err})
+		}
+	}
+	if n := len(row.Cell); fields > 0 && n != fields {
+		field := n - 1
+		if n > fields {
+			field = fields
+		}
+		problems = append(problems, Problem{Field: field, Err: ErrFieldCount})
+	}
+	return problems
+}
+
+// diagnoseCell returns the byte index of the first problem of the cell and its error.
+func diagnoseCell(source []byte, mode *Mode) (int, error) {
+	size := mode.unitSize()
+	if len(source) < size || mode.unitAt(source, 0) != '"' {
+		for i := 0; i+size <= len(source); i += size {
+			if mode.unitAt(source, i) == '"' {
+				return i, ErrBareQuote
+			}
+		}
+		return 0, nil
+	}
+	for i := size; i+size <= len(source); i += size {
+		if mode.unitAt(source, i) != '"' {
+			continue
+		}
+		if next := i + size; next+size <= len(source) && mode.unitAt(source, next) == '"' {
+			i = next // an escaped quote
+			continue
+		}
+		if i+size < len(source) {
+			return i, ErrQuote
+		}
+		return 0, nil
+	}
+	// the quote is not closed
+	return 0, ErrQuote
+}
+
+// Problems returns the problems of the row returned by the last Read
+// (see Row.Diagnose) with their positions. The number of the fields is
+// checked with FieldsPerRecord.
+func (r *Reader) Problems() []*ParseError {
+	if r.row == nil {
+		return nil
+	}
+	var errs []*ParseError
+	for _, p := range r.row.Diagnose(r.mode, r.FieldsPerRecord) {
+		pos := r.fields[p.Field]
+		pos.advance(r.row.Cell[p.Field].source[:p.Index], r.mode)
+		errs = append(errs, &ParseError{
+			StartLine: r.fields[0].line,
+			Line:      pos.line,
+			Column:    pos.column,
+			Offset:    pos.offset,
+			Err:       p.Err,
+		})
+	}
+	return errs
+}
+
+// Diagnose reads all the rows of r and returns their problems with the positions
+// (see Reader.Problems). The number of the fields of each row is compared
+// with the first row. The error is the one which stopped reading.
+func Diagnose(r io.Reader, mode *Mode) ([]*ParseError, error) {
+	reader := NewReader(r, mode)
+	var problems []*ParseError
+	for {
+		_, err := reader.Read()
+		if err == io.EOF {
+			return problems, nil
+		}
+		if err != nil && !errors.Is(err, ErrQuote) {
+			return problems, err
+		}
+		problems = append(problems, reader.Problems()...)
+	}
+}
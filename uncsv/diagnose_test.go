@@ -0,0 +1,48 @@
+package uncsv
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+	source := strings.Join([]string{
+		`a,b,c`,
+		`"x""y",z,w`,
+		`p,q"r"s,t`,
+		`"t"u,v,w`,
+		`1,2`,
+		`1,2,3,4`,
+		`"open,e`,
+		`f,g`,
+	}, "\n")
+	problems, err := Diagnose(strings.NewReader(source), nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var result []string
+	for _, p := range problems {
+		result = append(result, fmt.Sprintf("%d:%d:%d:%v", p.Line, p.Column, p.Offset, p.Err))
+	}
+	expect := []string{
+		"3:4:20:" + ErrBareQuote.Error(),
+		"4:3:29:" + ErrQuote.Error(),
+		"5:3:38:" + ErrFieldCount.Error(),
+		"6:7:46:" + ErrFieldCount.Error(),
+		// the quote not closed makes the rest one cell
+		"7:1:48:" + ErrQuote.Error(),
+		"7:1:48:" + ErrFieldCount.Error(),
+	}
+	if strings.Join(result, "|") != strings.Join(expect, "|") {
+		t.Fatalf("expect\n%q\nbut\n%q", expect, result)
+	}
+}
+
+func TestRowDiagnose(t *testing.T) {
+	mode := &Mode{Comma: ','}
+	row := NewRowFromStringSlice(mode, []string{`a"b`, "c,d", "e\nf"})
+	if problems := row.Diagnose(mode, 3); len(problems) != 0 {
+		t.Fatalf("the cells made by csvi have problems: %v", problems)
+	}
+}
@@ -0,0 +1,233 @@
+package uncsv
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"hash/fnv"
+	"io"
+	"sync"
+)
+
+// Source reads the rows of a file and reads them again on demand,
+// so that only the rows in use have to be kept in memory.
+// The rows returned by Source.ReadLine remember their place in the file,
+// and Row.Following makes the rows after them without keeping all of them.
+type Source struct {
+	r      *bufio.Reader
+	ra     io.ReaderAt
+	mode   *Mode
+	start  int64
+	offset int64
+	loaded []*Row
+
+	// The fields below are used also by the goroutine reading the rows again
+	// while ReadLine updates mode (Mode.NonUTF8 and so on) and ends.
+	reloadMu sync.Mutex
+	// reloadMode is the copy of mode to read the rows again.
+	reloadMode *Mode
+	// ends are the offsets of the ends of the rows in chunks of endsChunk
+	// so that the index of a large file is not copied as it grows.
+	ends [][]int64
+	n    int
+}
+
+const endsChunk = 4096
+
+// span is the place of a row in the file of Source.
+type span struct {
+	src   *Source
+	index int
+	// sum is the checksum of the bytes read last to find the changes
+	sum uint64
+	// err is the error which occurred when the row was read again
+	err error
+}
+
+// NewSource returns the Source which reads the rows from r sequentially.
+// ra must read the same data as r and is used to read the rows again,
+// so the data must not be changed while the rows are used.
+func NewSource(r io.Reader, ra io.ReaderAt, mode *Mode) *Source {
+	return &Source{r: bufio.NewReader(r), ra: ra, mode: mode}
+}
+
+var errNotFromSource = errors.New("the row is not read by Source")
+
+func checksum(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
+
+// ReadLine reads the next row like ReadLine function.
+// The cells of the row are kept until Row.Unload is called.
+func (s *Source) ReadLine() (*Row, error) {
+	if s.mode.hasBom == triNotSet {
+		s.mode.DetectBom(s.r)
+		s.start = s.mode.bomSize()
+		s.offset = s.start
+	}
+	row, err := ReadLine(s.r, s.mode)
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	if m := s.reloadMode; m == nil || m.NonUTF8 != s.mode.NonUTF8 || m.DefaultTerm != s.mode.DefaultTerm {
+		s.reloadMode = s.mode.clone()
+	}
+	if row.IsZero() {
+		return row, err
+	}
+	data := row.rebuild(s.mode)
+	s.offset += int64(len(data))
+	if s.n%endsChunk == 0 {
+		s.ends = append(s.ends, make([]int64, 0, endsChunk))
+	}
+	last := len(s.ends) - 1
+	s.ends[last] = append(s.ends[last], s.offset)
+	row.span = &span{src: s, index: s.n, sum: checksum(data)}
+	s.n++
+	return row, err
+}
+
+// Len returns the number of the rows read by ReadLine so far.
+func (s *Source) Len() int {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	return s.n
+}
+
+// locate returns the byte range of the row at index.
+func (s *Source) locate(index int) (int64, int) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	offset := s.start
+	if index > 0 {
+		offset = s.ends[(index-1)/endsChunk][(index-1)%endsChunk]
+	}
+	return offset, int(s.ends[index/endsChunk][index%endsChunk] - offset)
+}
+
+// Unload unloads the rows loaded by Row.Load except the rows
+// for which keep returns true and the rows changed.
+func (s *Source) Unload(keep func(*Row) bool) {
+	n := 0
+	for _, row := range s.loaded {
+		if row.Cell != nil && (keep(row) || !row.Unload()) {
+			s.loaded[n] = row
+			n++
+		}
+	}
+	for i := n; i < len(s.loaded); i++ {
+		s.loaded[i] = nil
+	}
+	s.loaded = s.loaded[:n]
+}
+
+func (sp *span) read() ([]byte, error) {
+	offset, size := sp.src.locate(sp.index)
+	data := make([]byte, size)
+	n, err := sp.src.ra.ReadAt(data, offset)
+	if n == len(data) {
+		return data, nil
+	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, err
+}
+
+func (sp *span) parse() (*Row, error) {
+	data, err := sp.read()
+	if err != nil {
+		return nil, err
+	}
+	sp.sum = checksum(data)
+	s := sp.src
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	row, err := ReadLine(bufio.NewReader(bytes.NewReader(data)), s.reloadMode)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return row, nil
+}
+
+// IsLoaded reports whether the cells of the row are in memory.
+// It is always true for the rows not read by Source.
+func (row *Row) IsLoaded() bool {
+	return row.span == nil || row.Cell != nil
+}
+
+// Load reads the cells of the row unloaded again from the file.
+// When the file can not be read, the row gets an empty cell
+// and Mode.DumpBy fails on it.
+func (row *Row) Load() error {
+	if row.IsLoaded() {
+		return nil
+	}
+	loaded, err := row.span.parse()
+	if err != nil {
+		row.span.err = err
+		row.Cell = []Cell{{}}
+		return err
+	}
+	row.Cell = loaded.Cell
+	row.Term = loaded.Term
+	row.span.src.loaded = append(row.span.src.loaded, row)
+	return nil
+}
+
+// InSource reports whether the row is the same as the bytes in the file of Source,
+// that is, it can be unloaded and read again.
+func (row *Row) InSource() bool {
+	if row.span == nil || row.span.err != nil {
+		return false
+	}
+	if row.Cell == nil {
+		return true
+	}
+	for _, c := range row.Cell {
+		if c.Modified() {
+			return false
+		}
+	}
+	return checksum(row.rebuild(row.span.src.mode)) == row.span.sum
+}
+
+// Unload releases the cells of the row read by Source unless they are changed.
+// It reports whether the row is unloaded.
+func (row *Row) Unload() bool {
+	if !row.InSource() {
+		return false
+	}
+	row.Cell = nil
+	return true
+}
+
+// ReadSource returns a new row read again from the file of Source
+// regardless of the changes of the row.
+func (row *Row) ReadSource() (*Row, error) {
+	if row.span == nil {
+		return nil, errNotFromSource
+	}
+	sp := *row.span
+	return sp.parse()
+}
+
+// Following returns the row unloaded which is n rows after the row in the file of Source,
+// or nil when the row is not read by Source or the row is not read yet.
+func (row *Row) Following(n int) *Row {
+	if row.span == nil {
+		return nil
+	}
+	index := row.span.index + n
+	if index < 0 || index >= row.span.src.Len() {
+		return nil
+	}
+	return &Row{span: &span{src: row.span.src, index: index}}
+}
+
+// Follows reports whether the row is read by the same Source n rows after head.
+func (row *Row) Follows(head *Row, n int) bool {
+	return row.span != nil && head.span != nil &&
+		row.span.src == head.span.src && row.span.index == head.span.index+n
+}
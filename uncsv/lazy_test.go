@@ -0,0 +1,170 @@
+package uncsv
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func readSource(t *testing.T, source string) ([]*Row, *Mode) {
+	t.Helper()
+	mode := &Mode{Comma: ','}
+	r := strings.NewReader(source)
+	src := NewSource(r, strings.NewReader(source), mode)
+	var rows []*Row
+	for {
+		row, err := src.ReadLine()
+		if err != nil && err != io.EOF {
+			t.Fatal(err.Error())
+		}
+		if !row.IsZero() {
+			if !row.Unload() {
+				t.Fatal("the row not changed is not unloaded")
+			}
+			rows = append(rows, row)
+		}
+		if err == io.EOF {
+			return rows, mode
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+	rows, mode := readSource(t, "\uFEFFa,b\r\n\"c\nd\",e\r\nf")
+	if len(rows) != 3 {
+		t.Fatalf("expect 3 rows, but %d", len(rows))
+	}
+	if err := rows[1].Load(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if text := rows[1].Cell[0].Text(); text != "c\nd" {
+		t.Fatalf("expect %q, but %q", "c\nd", text)
+	}
+	rows[1].Replace(1, "X", mode)
+	if rows[1].Unload() {
+		t.Fatal("the row changed is unloaded")
+	}
+	rows[2].Load()
+	rows[2].Replace(0, "f", mode)
+	if !rows[2].Unload() {
+		t.Fatal("the row with the same text is not unloaded")
+	}
+	if rows[0].IsLoaded() || rows[0].IsZero() {
+		t.Fatal("the row unloaded is not kept")
+	}
+
+	var buffer strings.Builder
+	i := 0
+	mode.DumpBy(context.Background(), func() *Row {
+		if i >= len(rows) {
+			return nil
+		}
+		i++
+		return rows[i-1]
+	}, &buffer)
+	expect := "\uFEFFa,b\r\n\"c\nd\",X\r\nf"
+	if result := buffer.String(); result != expect {
+		t.Fatalf("expect %q, but %q", expect, result)
+	}
+	if old, err := rows[1].ReadSource(); err != nil || old.Cell[1].Text() != "e" {
+		t.Fatalf("the row in the file is not read: %v", err)
+	}
+}
+
+func TestSourceUnload(t *testing.T) {
+	rows, mode := readSource(t, "a\nb\nc\n")
+	for _, row := range rows {
+		row.Load()
+	}
+	rows[2].Replace(0, "C", mode)
+	rows[0].span.src.Unload(func(row *Row) bool { return row == rows[0] })
+	if !rows[0].IsLoaded() || rows[1].IsLoaded() || !rows[2].IsLoaded() {
+		t.Fatal("only the row neither kept nor changed must be unloaded")
+	}
+	if n := len(rows[0].span.src.loaded); n != 2 {
+		t.Fatalf("expect 2 rows loaded, but %d", n)
+	}
+}
+
+func TestSourceReloadWhileReading(t *testing.T) {
+	// the rows are read again while the rest are read on another goroutine
+	// which finds the data not to be UTF-8 (run with -race)
+	var data strings.Builder
+	for i := 0; i < 1000; i++ {
+		data.WriteString("a,b\n\x82\xa0,\x82\xa2\n")
+	}
+	source := data.String()
+	mode := &Mode{Comma: ','}
+	if err := mode.SetEncoding("Shift_JIS"); err != nil {
+		t.Fatal(err.Error())
+	}
+	src := NewSource(strings.NewReader(source), strings.NewReader(source), mode)
+	rows := make(chan *Row, 10)
+	go func() {
+		defer close(rows)
+		for {
+			row, err := src.ReadLine()
+			if !row.IsZero() {
+				row.Unload()
+				rows <- row
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	n := 0
+	for row := range rows {
+		if err := row.Load(); err != nil {
+			t.Fatal(err.Error())
+		}
+		expect := "a"
+		if n%2 == 1 {
+			expect = "あ"
+		}
+		if text := row.Cell[0].Text(); text != expect {
+			t.Fatalf("row %d: expect %q, but %q", n, expect, text)
+		}
+		n++
+	}
+	if n != 2000 {
+		t.Fatalf("expect 2000 rows, but %d", n)
+	}
+}
+
+func TestSourceFollowing(t *testing.T) {
+	// enough rows for two or more chunks of the offsets
+	var data strings.Builder
+	for i := 0; i < endsChunk+10; i++ {
+		fmt.Fprintf(&data, "%d,\"x\ny\"\r\n", i)
+	}
+	rows, mode := readSource(t, "\uFEFF"+data.String())
+	head := rows[0]
+	for _, n := range []int{1, endsChunk - 1, endsChunk, endsChunk + 9} {
+		row := head.Following(n)
+		if row.IsLoaded() || !row.Follows(head, n) || !rows[n].Follows(head, n) {
+			t.Fatalf("Following(%d): not the row unloaded after the head", n)
+		}
+		if err := row.Load(); err != nil {
+			t.Fatal(err.Error())
+		}
+		if text := row.Cell[0].Text(); text != strconv.Itoa(n) {
+			t.Fatalf("Following(%d): expect %d, but %q", n, n, text)
+		}
+		if string(row.Rebuild(mode)) != string(rows[n].Rebuild(mode)) {
+			t.Fatalf("Following(%d): the bytes differ", n)
+		}
+		if !row.Unload() {
+			t.Fatalf("Following(%d): not unloaded", n)
+		}
+	}
+	if row := head.Following(endsChunk + 10); row != nil {
+		t.Fatal("the row after the end is made")
+	}
+	if (&Row{}).Following(1) != nil || rows[2].Follows(head, 1) {
+		t.Fatal("the row not following is made")
+	}
+}
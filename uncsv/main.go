@@ -43,6 +43,8 @@ type Mode struct {
 	endian      endian
 	decoder     *encoding.Decoder
 	encoder     *encoding.Encoder
+	// encoding is the encoding of decoder and encoder
+	encoding encoding.Encoding
 }
 
 func (m *Mode) IsUTF16LE() bool {
@@ -88,10 +90,21 @@ func (m *Mode) _encode(s string) ([]byte, error) {
 }
 
 func (m *Mode) setEncoding(e encoding.Encoding) {
+	m.encoding = e
 	m.decoder = e.NewDecoder()
 	m.encoder = e.NewEncoder()
 }
 
+// clone returns the copy of the mode which can be used on another goroutine.
+// The decoder and the encoder, which have states, are not shared.
+func (m *Mode) clone() *Mode {
+	c := *m
+	if m.encoding != nil {
+		c.setEncoding(m.encoding)
+	}
+	return &c
+}
+
 func (m *Mode) SetEncoding(name string) error {
 	e, err := ianaindex.IANA.Encoding(name)
 	if err != nil {
@@ -108,6 +121,77 @@ func (m *Mode) HasBom() bool {
 	return m.hasBom == triTrue
 }
 
+// SetBom sets whether the data has the BOM, which is written by Writer and Mode.Dump.
+// It is usually detected with the first row. Once it is set,
+// ReadLine does not detect it and the BOM is read as a part of the first cell.
+func (m *Mode) SetBom(bom bool) {
+	if bom {
+		m.hasBom = triTrue
+	} else {
+		m.hasBom = triFalse
+	}
+}
+
+// bomSize returns the size of the BOM in the encoding.
+func (m *Mode) bomSize() int64 {
+	if m.hasBom != triTrue {
+		return 0
+	}
+	if m.endian == octet {
+		return 3
+	}
+	return 2
+}
+
+func (m *Mode) writeBom(w io.Writer) {
+	if m.hasBom != triTrue {
+		return
+	}
+	switch m.endian {
+	case utf16le:
+		w.Write([]byte{0xFF, 0xFE})
+	case utf16be:
+		w.Write([]byte{0xFE, 0xFF})
+	default:
+		io.WriteString(w, "\uFEFF")
+	}
+}
+
+// DetectBom skips the BOM at the head of br and sets the encoding by it
+// or by the zero bytes of UTF-16 as ReadLine does for the first row.
+// It uses the bytes available even when they are fewer than it needs,
+// so it is called before ReadLine for the reader which waits for data at the end.
+func (mode *Mode) DetectBom(br *bufio.Reader) {
+	prefix, _ := br.Peek(peekSize)
+	if len(prefix) <= 0 {
+		return
+	}
+	if bytes.HasPrefix(prefix, []byte{0xEF, 0xBB, 0xBF}) {
+		// UTF8
+		mode.hasBom = triTrue
+		br.Discard(3)
+	} else if bytes.HasPrefix(prefix, []byte{0xFF, 0xFE}) {
+		mode.hasBom = triTrue
+		mode.SetUTF16LE()
+		br.Discard(2)
+	} else if bytes.HasPrefix(prefix, []byte{0xFE, 0xFF}) {
+		mode.hasBom = triTrue
+		mode.SetUTF16BE()
+		br.Discard(2)
+	} else {
+		mode.hasBom = triFalse
+		if mode.endian != utf16le && mode.endian != utf16be {
+			if idx := bytes.IndexByte(prefix, 0); idx >= 0 {
+				if idx%2 == 1 {
+					mode.SetUTF16LE()
+				} else {
+					mode.SetUTF16BE()
+				}
+			}
+		}
+	}
+}
+
 func (m *Mode) decode(s []byte) string {
 	if !m.NonUTF8 && utf8.Valid(s) {
 		return string(s)
@@ -202,13 +286,15 @@ type Row struct {
 	Cell []Cell
 	// Term is one of "", "\n", and "\r\n"
 	Term string
+	// span is set for the row read by Source. Cell is nil while it is unloaded.
+	span *span
 }
 
 func (r *Row) IsZero() bool {
 	if r == nil {
 		return true
 	}
-	if r.Term != "" {
+	if r.span != nil || r.Term != "" {
 		return false
 	}
 	if len(r.Cell) <= 0 {
@@ -225,33 +311,7 @@ func ReadLine(br *bufio.Reader, mode *Mode) (*Row, error) {
 	quoted := false
 	source := []byte{}
 	if mode.hasBom == triNotSet {
-		prefix, err := br.Peek(peekSize)
-		if err == nil {
-			if bytes.HasPrefix(prefix, []byte{0xEF, 0xBB, 0xBF}) {
-				// UTF8
-				mode.hasBom = triTrue
-				br.Discard(3)
-			} else if bytes.HasPrefix(prefix, []byte{0xFF, 0xFE}) {
-				mode.hasBom = triTrue
-				mode.SetUTF16LE()
-				br.Discard(2)
-			} else if bytes.HasPrefix(prefix, []byte{0xFE, 0xFF}) {
-				mode.hasBom = triTrue
-				mode.SetUTF16BE()
-				br.Discard(2)
-			} else {
-				mode.hasBom = triFalse
-				if mode.endian != utf16le && mode.endian != utf16be {
-					if idx := bytes.IndexByte(prefix, 0); idx >= 0 {
-						if idx%2 == 1 {
-							mode.SetUTF16LE()
-						} else {
-							mode.SetUTF16BE()
-						}
-					}
-				}
-			}
-		}
+		mode.DetectBom(br)
 	}
 	if mode.endian == octet {
 		for {
@@ -385,7 +445,27 @@ func writeEndian(w io.Writer, c byte, e endian) {
 	}
 }
 
+// Rebuild returns the bytes of the row.
+// For the row unloaded, they are read from the file of Source
+// and nil is returned when it fails.
 func (row *Row) Rebuild(mode *Mode) []byte {
+	data, _ := row.bytes(mode)
+	return data
+}
+
+func (row *Row) bytes(mode *Mode) ([]byte, error) {
+	if row.span != nil {
+		if row.span.err != nil {
+			return nil, row.span.err
+		}
+		if row.Cell == nil {
+			return row.span.read()
+		}
+	}
+	return row.rebuild(mode), nil
+}
+
+func (row *Row) rebuild(mode *Mode) []byte {
 	var buffer bytes.Buffer
 	if len(row.Cell) > 0 {
 		for i, end := 0, len(row.Cell); ; {
@@ -416,16 +496,7 @@ func (mode *Mode) Dump(ctx context.Context, rows []Row, w io.Writer) error {
 func (mode *Mode) DumpBy(ctx context.Context, fetch func() *Row, w io.Writer) error {
 	bw := bufio.NewWriter(w)
 	defer bw.Flush()
-	if mode.hasBom == triTrue {
-		switch mode.endian {
-		case utf16le:
-			bw.Write([]byte{0xFF, 0xFE})
-		case utf16be:
-			bw.Write([]byte{0xFE, 0xFF})
-		default:
-			bw.WriteString("\uFEFF")
-		}
-	}
+	mode.writeBom(bw)
 	for {
 		if err := ctx.Err(); err != nil {
 			return err
@@ -434,7 +505,11 @@ func (mode *Mode) DumpBy(ctx context.Context, fetch func() *Row, w io.Writer) er
 		if row == nil {
 			return nil
 		}
-		bw.Write(row.Rebuild(mode))
+		data, err := row.bytes(mode)
+		if err != nil {
+			return err
+		}
+		bw.Write(data)
 	}
 }
 
@@ -81,6 +81,13 @@ func TestDeleteWithBom(t *testing.T) {
 	})
 }
 
+func TestShortWithBom(t *testing.T) {
+	// the BOM is detected even when the data is shorter than peekSize
+	upd(t, "\uFEFFa\n", "\uFEFFb\n", func(r []Row, m *Mode) {
+		r[0].Replace(0, "b", m)
+	})
+}
+
 func TestSlicesInsert(t *testing.T) {
 	source := []byte{1, 2, 3, 4}
 	result := slicesInsert(source, 2, 7)
@@ -0,0 +1,249 @@
+package uncsv
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nyaosorg/go-windows-mbcs"
+)
+
+// parallelChunkSize is the size of the data read at once to be split into chunks.
+const parallelChunkSize = 256 * 1024
+
+// ParallelReader reads the rows like ReadLine parsing the data in chunks
+// on multiple goroutines. The data is split only at the line feeds
+// outside of the quotes, and the rows are returned in order.
+// UTF-16, the encodings set by Mode.SetEncoding and the data on a single CPU
+// are read sequentially.
+type ParallelReader struct {
+	br      *bufio.Reader
+	mode    *Mode
+	started bool
+	// sequential is true when the data is read by ReadLine function
+	sequential bool
+	// base is the mode which the chunks are parsed with
+	base Mode
+	// nonUTF8 is set when a chunk is found not to be UTF-8
+	nonUTF8 atomic.Bool
+
+	jobs    chan *chunkJob
+	stop    chan struct{}
+	once    sync.Once
+	current *chunkJob
+	pos     int
+}
+
+// chunkJob is the rows of a chunk parsed by a goroutine.
+type chunkJob struct {
+	data []byte
+	// last is true for the chunk at the end of the data
+	last bool
+	// readErr is the error other than io.EOF which stopped reading
+	readErr error
+	// mode is the copy of ParallelReader.base which the chunk is parsed with
+	mode    Mode
+	nonUTF8 bool
+	rows    []*Row
+	err     error
+	done    chan struct{}
+}
+
+// NewParallelReader returns the ParallelReader which reads the rows from r.
+// The reader should be a file: a chunk is parsed when its end is read,
+// so the rows are returned later than ReadLine from a pipe.
+func NewParallelReader(r io.Reader, mode *Mode) *ParallelReader {
+	return &ParallelReader{
+		br:   bufio.NewReader(r),
+		mode: mode,
+		stop: make(chan struct{}),
+	}
+}
+
+func (p *ParallelReader) start() {
+	p.started = true
+	if p.mode.hasBom == triNotSet {
+		p.mode.DetectBom(p.br)
+	}
+	workers := runtime.GOMAXPROCS(0)
+	if workers < 2 || p.mode.hasBom == triNotSet || p.mode.endian != octet || p.mode.decoder != nil {
+		p.sequential = true
+		return
+	}
+	p.base = *p.mode
+	// let the ANSI code page of the OS be cached before the goroutines use it
+	mbcs.AnsiToUtf8(nil, mbcs.ACP)
+	p.jobs = make(chan *chunkJob, workers*2)
+	queue := make(chan *chunkJob)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range queue {
+				job.parse()
+				if job.mode.NonUTF8 {
+					p.nonUTF8.Store(true)
+				}
+				close(job.done)
+			}
+		}()
+	}
+	go p.split(queue)
+}
+
+// split reads the data and sends the chunks which end at the end of rows.
+func (p *ParallelReader) split(queue chan<- *chunkJob) {
+	defer close(p.jobs)
+	defer close(queue)
+	buf := make([]byte, 0, parallelChunkSize)
+	scanned := 0
+	quoted := false
+	for {
+		if len(buf) >= cap(buf) {
+			// no rows end in the buffer
+			newBuf := make([]byte, len(buf), cap(buf)*2)
+			copy(newBuf, buf)
+			buf = newBuf
+		}
+		n, err := p.br.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err != nil {
+			job := &chunkJob{data: buf, last: true}
+			if err != io.EOF {
+				job.readErr = err
+			}
+			p.dispatch(job, queue)
+			return
+		}
+		end := -1
+		for i := scanned; i < len(buf); i++ {
+			switch buf[i] {
+			case '"':
+				quoted = !quoted
+			case '\n':
+				if !quoted {
+					end = i + 1
+				}
+			}
+		}
+		scanned = len(buf)
+		if end < 0 {
+			continue
+		}
+		rest := make([]byte, len(buf)-end, parallelChunkSize+len(buf)-end)
+		copy(rest, buf[end:])
+		if !p.dispatch(&chunkJob{data: buf[:end]}, queue) {
+			return
+		}
+		buf = rest
+		scanned = len(rest)
+	}
+}
+
+// dispatch sends the job to ReadLine in order and to a goroutine to parse it.
+// It returns false when ParallelReader is closed.
+func (p *ParallelReader) dispatch(job *chunkJob, queue chan<- *chunkJob) bool {
+	job.mode = p.base
+	job.mode.NonUTF8 = p.base.NonUTF8 || p.nonUTF8.Load()
+	job.nonUTF8 = job.mode.NonUTF8
+	job.done = make(chan struct{})
+	select {
+	case p.jobs <- job:
+	case <-p.stop:
+		return false
+	}
+	select {
+	case queue <- job:
+	case <-p.stop:
+		return false
+	}
+	return true
+}
+
+func (job *chunkJob) parse() {
+	br := bufio.NewReader(bytes.NewReader(job.data))
+	job.rows = job.rows[:0]
+	for {
+		row, err := ReadLine(br, &job.mode)
+		if err != nil {
+			// the chunk other than the last ends with a line feed
+			// and the empty row after it is not a row
+			if job.last {
+				job.rows = append(job.rows, row)
+				job.err = err
+				if job.readErr != nil {
+					job.err = job.readErr
+				}
+			}
+			return
+		}
+		job.rows = append(job.rows, row)
+	}
+}
+
+// ReadLine reads the next row like ReadLine function.
+func (p *ParallelReader) ReadLine() (*Row, error) {
+	if !p.started {
+		p.start()
+	}
+	if p.sequential {
+		return ReadLine(p.br, p.mode)
+	}
+	for {
+		select {
+		case <-p.stop:
+			return nil, io.EOF
+		default:
+		}
+		if job := p.current; job != nil && p.pos < len(job.rows) {
+			row := job.rows[p.pos]
+			p.pos++
+			if job.last && p.pos >= len(job.rows) {
+				return row, job.err
+			}
+			return row, nil
+		}
+		var job *chunkJob
+		select {
+		case j, ok := <-p.jobs:
+			if !ok {
+				// all the data is read
+				return ReadLine(p.br, p.mode)
+			}
+			job = j
+		case <-p.stop:
+			return nil, io.EOF
+		}
+		select {
+		case <-job.done:
+		case <-p.stop:
+			return nil, io.EOF
+		}
+		p.merge(job)
+		p.current = job
+		p.pos = 0
+	}
+}
+
+// merge updates the mode as ReadLine function does for the rows of the job.
+func (p *ParallelReader) merge(job *chunkJob) {
+	if job.nonUTF8 != p.mode.NonUTF8 {
+		// the chunk was parsed before it was known whether the rows before it are UTF-8
+		job.mode = p.base
+		job.mode.NonUTF8 = p.mode.NonUTF8
+		job.parse()
+	}
+	if job.mode.NonUTF8 {
+		p.mode.NonUTF8 = true
+	}
+	if p.mode.DefaultTerm == "" {
+		p.mode.DefaultTerm = job.mode.DefaultTerm
+	}
+}
+
+// Close stops the goroutines reading the rows.
+// ReadLine returns io.EOF after it is closed.
+func (p *ParallelReader) Close() {
+	p.once.Do(func() { close(p.stop) })
+}
@@ -0,0 +1,154 @@
+package uncsv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type readResult struct {
+	data string
+	term string
+	err  error
+}
+
+func readAllRows(t testing.TB, readLine func() (*Row, error), mode *Mode) []readResult {
+	t.Helper()
+	var result []readResult
+	for {
+		row, err := readLine()
+		if err != nil && err != io.EOF {
+			t.Fatal(err.Error())
+		}
+		result = append(result, readResult{
+			data: string(row.Rebuild(mode)),
+			term: row.Term,
+			err:  err,
+		})
+		if err == io.EOF {
+			return result
+		}
+	}
+}
+
+func testParallel(t *testing.T, source string, wrap func(io.Reader) io.Reader) {
+	t.Helper()
+	seqMode := &Mode{Comma: ','}
+	br := bufio.NewReader(strings.NewReader(source))
+	expect := readAllRows(t, func() (*Row, error) { return ReadLine(br, seqMode) }, seqMode)
+
+	mode := &Mode{Comma: ','}
+	p := NewParallelReader(wrap(strings.NewReader(source)), mode)
+	defer p.Close()
+	result := readAllRows(t, p.ReadLine, mode)
+
+	if len(result) != len(expect) {
+		t.Fatalf("expect %d rows, but %d", len(expect), len(result))
+	}
+	for i := range expect {
+		if result[i] != expect[i] {
+			t.Fatalf("row %d: expect %#v, but %#v", i, expect[i], result[i])
+		}
+	}
+	if mode.DefaultTerm != seqMode.DefaultTerm || mode.NonUTF8 != seqMode.NonUTF8 || mode.HasBom() != seqMode.HasBom() {
+		t.Fatalf("expect %#v, but %#v", seqMode, mode)
+	}
+}
+
+// makeRows returns the rows which have cells with line feeds and quotes
+// so that the chunks are split inside of them.
+func makeRows(n int, term string) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "%d,\"line\n%d\",\"say \"\"%d\"\"\",%s%s", i, i, i, strings.Repeat("x", i%37), term)
+	}
+	return b.String()
+}
+
+func TestParallelReader(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	asIs := func(r io.Reader) io.Reader { return r }
+	big := makeRows(30000, "\r\n")
+	for name, source := range map[string]string{
+		"empty":      "",
+		"short":      "a,b\nc,d",
+		"terminated": "a,b\nc,d\n",
+		"big":        big,
+		"bom":        "\uFEFF" + big + "end",
+		"lf":         makeRows(30000, "\n"),
+		"quoted":     "a,\"" + strings.Repeat("b\n", parallelChunkSize) + "\"\nc",
+		"unclosed":   big + "\"" + big,
+		"utf16":      "a\x00,\x00b\x00\n\x00",
+		"nonUTF8":    big + "\x82\xa0,b\n" + big,
+	} {
+		t.Run(name, func(t *testing.T) {
+			testParallel(t, source, asIs)
+		})
+	}
+	t.Run("half", func(t *testing.T) {
+		testParallel(t, big, iotest.HalfReader)
+	})
+}
+
+func TestParallelReaderClose(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	p := NewParallelReader(strings.NewReader(makeRows(100000, "\n")), &Mode{Comma: ','})
+	if _, err := p.ReadLine(); err != nil {
+		t.Fatal(err.Error())
+	}
+	p.Close()
+	if _, err := p.ReadLine(); err != io.EOF {
+		t.Fatalf("expect io.EOF after Close, but %v", err)
+	}
+}
+
+// benchmarkRows are the rows like 27OSAKA.CSV of test/benchmark.ps1,
+// the postal codes of Osaka.
+func benchmarkRows(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "27%03d,\"%03d  \",\"%07d\",\"ｵｵｻｶﾌ\",\"ｵｵｻｶｼｷﾀｸ\",\"ｳﾒﾀﾞ%d\",\"大阪府\",\"大阪市北区\",\"梅田%d丁目\",0,0,1,0,0,0\r\n",
+			i%1000, i%1000, 5300000+i, i, i)
+	}
+	return b.String()
+}
+
+const benchmarkRowCount = 100000
+
+func benchmarkReadLine(b *testing.B, newReadLine func(io.Reader, *Mode) func() (*Row, error)) {
+	source := benchmarkRows(benchmarkRowCount)
+	b.SetBytes(int64(len(source)))
+	b.ResetTimer()
+	rows := 0
+	for i := 0; i < b.N; i++ {
+		mode := &Mode{Comma: ','}
+		readLine := newReadLine(strings.NewReader(source), mode)
+		for {
+			row, err := readLine()
+			if !row.IsZero() {
+				rows++
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+	b.ReportMetric(float64(rows)/b.Elapsed().Seconds(), "rows/s")
+}
+
+func BenchmarkReadLine(b *testing.B) {
+	benchmarkReadLine(b, func(r io.Reader, mode *Mode) func() (*Row, error) {
+		br := bufio.NewReader(r)
+		return func() (*Row, error) { return ReadLine(br, mode) }
+	})
+}
+
+func BenchmarkParallelReader(b *testing.B) {
+	benchmarkReadLine(b, func(r io.Reader, mode *Mode) func() (*Row, error) {
+		return NewParallelReader(r, mode).ReadLine
+	})
+}
@@ -0,0 +1,225 @@
+package uncsv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrQuote is the error of the data which ends in a quoted field.
+var ErrQuote = errors.New("extraneous or missing \" in quoted-field")
+
+// ParseError is the error of Reader with its position.
+type ParseError struct {
+	StartLine int   // the line where the row starts
+	Line      int   // the line where the error occurred
+	Column    int   // the byte index in the line where the error occurred (1-based)
+	Offset    int64 // the byte offset in the data where the error occurred
+	Err       error
+}
+
+func (e *ParseError) Error() string {
+	if e.StartLine != e.Line {
+		return fmt.Sprintf("record on line %d; parse error on line %d, column %d: %v",
+			e.StartLine, e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("parse error on line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// position is the place of the data. line and column are 1-based.
+type position struct {
+	line   int
+	column int
+	offset int64
+}
+
+// unitSize returns the size of an ASCII character in the encoding.
+func (m *Mode) unitSize() int {
+	if m.endian == octet {
+		return 1
+	}
+	return 2
+}
+
+// unitAt returns the unit of the encoding at data[i:] as a character.
+// Only ASCII characters are compared with it.
+func (m *Mode) unitAt(data []byte, i int) rune {
+	switch m.endian {
+	case utf16le:
+		return rune(data[i]) | rune(data[i+1])<<8
+	case utf16be:
+		return rune(data[i])<<8 | rune(data[i+1])
+	default:
+		return rune(data[i])
+	}
+}
+
+// advanceChar moves the position over an ASCII character in the encoding.
+func (p *position) advanceChar(c rune, mode *Mode) {
+	size := mode.unitSize()
+	if c == '\n' {
+		p.line++
+		p.column = 1
+	} else {
+		p.column += size
+	}
+	p.offset += int64(size)
+}
+
+// advance moves the position over data encoded in the mode.
+func (p *position) advance(data []byte, mode *Mode) {
+	for i, size := 0, mode.unitSize(); i+size <= len(data); i += size {
+		p.advanceChar(mode.unitAt(data, i), mode)
+	}
+}
+
+// Reader reads the rows like encoding/csv.Reader keeping the bytes of the cells,
+// so that the rows not changed are written back as they were.
+// It also tells where each field is in the data.
+type Reader struct {
+	// FieldsPerRecord is the number of the fields which Problems expects
+	// for each row like encoding/csv.Reader. When it is 0, it is set to
+	// the number of the fields of the first row. A negative value disables the check.
+	FieldsPerRecord int
+
+	br     *bufio.Reader
+	mode   *Mode
+	pos    position
+	row    *Row
+	fields []position
+	err    error
+}
+
+// NewReader returns the Reader which reads the rows from r.
+// The BOM and the encoding are detected with the first row unless they are set to mode.
+// When mode is nil, the fields are separated by commas.
+func NewReader(r io.Reader, mode *Mode) *Reader {
+	if mode == nil {
+		mode = &Mode{Comma: ','}
+	}
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &Reader{
+		br:   br,
+		mode: mode,
+		pos:  position{line: 1, column: 1},
+	}
+}
+
+// Mode returns the mode which the rows are read with.
+func (r *Reader) Mode() *Mode {
+	return r.mode
+}
+
+// Read reads the next row. It returns nil and io.EOF after the last row.
+// When the data ends in a quoted field, it returns the row with the rest of
+// the data in the last cell and a *ParseError of ErrQuote.
+func (r *Reader) Read() (*Row, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.mode.hasBom == triNotSet {
+		r.mode.DetectBom(r.br)
+		r.pos.offset = r.mode.bomSize()
+	}
+	row, err := ReadLine(r.br, r.mode)
+	if err != nil {
+		r.err = err
+		if row.IsZero() {
+			r.row = nil
+			return nil, err
+		}
+	}
+	r.row = row
+	if r.FieldsPerRecord == 0 {
+		r.FieldsPerRecord = len(row.Cell)
+	}
+	startLine := r.pos.line
+	r.fields = r.fields[:0]
+	for i, c := range row.Cell {
+		if i > 0 {
+			r.pos.advanceChar(rune(r.mode.Comma), r.mode)
+		}
+		r.fields = append(r.fields, r.pos)
+		r.pos.advance(c.source, r.mode)
+	}
+	for i := 0; i < len(row.Term); i++ {
+		r.pos.advanceChar(rune(row.Term[i]), r.mode)
+	}
+	if err == io.EOF {
+		last := len(row.Cell) - 1
+		if !inQuotes(row.Cell[last].source, r.mode) {
+			return row, nil
+		}
+		field := r.fields[last]
+		return row, &ParseError{
+			StartLine: startLine,
+			Line:      field.line,
+			Column:    field.column,
+			Offset:    field.offset,
+			Err:       ErrQuote,
+		}
+	}
+	return row, err
+}
+
+// ReadAll reads the rest of the rows.
+// It returns the rows read before the error and the error other than io.EOF.
+func (r *Reader) ReadAll() ([]*Row, error) {
+	var rows []*Row
+	for {
+		row, err := r.Read()
+		if row != nil {
+			rows = append(rows, row)
+		}
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+	}
+}
+
+// FieldPos returns the line and the column (a 1-based byte index in the line)
+// where the field of the row returned by the last Read starts.
+// It panics when field is out of range.
+func (r *Reader) FieldPos(field int) (line, column int) {
+	if field < 0 || field >= len(r.fields) {
+		panic("out of range index passed to FieldPos")
+	}
+	p := r.fields[field]
+	return p.line, p.column
+}
+
+// FieldOffset returns the byte offset in the data
+// where the field of the row returned by the last Read starts.
+// It panics when field is out of range.
+func (r *Reader) FieldOffset(field int) int64 {
+	if field < 0 || field >= len(r.fields) {
+		panic("out of range index passed to FieldOffset")
+	}
+	return r.fields[field].offset
+}
+
+// InputOffset returns the byte offset in the data
+// where the row returned by the last Read ends, including the BOM.
+func (r *Reader) InputOffset() int64 {
+	return r.pos.offset
+}
+
+// inQuotes reports whether the quote of the cell is not closed.
+func inQuotes(source []byte, mode *Mode) bool {
+	quoted := false
+	for i, size := 0, mode.unitSize(); i+size <= len(source); i += size {
+		if mode.unitAt(source, i) == '"' {
+			quoted = !quoted
+		}
+	}
+	return quoted
+}
@@ -0,0 +1,95 @@
+package uncsv
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReaderRoundTrip(t *testing.T) {
+	for _, source := range []string{
+		"",
+		"a,b\r\nc,d\r\n",
+		"\uFEFFa, \"b\"\"\" ,c\n\"d\ne\",f",
+		"\xFF\xFEa\x00,\x00b\x00\r\x00\n\x00",
+		"\n\na,b\n",
+	} {
+		r := NewReader(strings.NewReader(source), nil)
+		rows, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if n := r.InputOffset(); n != int64(len(source)) {
+			t.Fatalf("%q: InputOffset expects %d, but %d", source, len(source), n)
+		}
+		var buffer strings.Builder
+		if err := NewWriter(&buffer, r.Mode()).WriteAll(rows); err != nil {
+			t.Fatal(err.Error())
+		}
+		if result := buffer.String(); result != source {
+			t.Fatalf("expect %q, but %q", source, result)
+		}
+	}
+}
+
+func TestReaderFieldPos(t *testing.T) {
+	r := NewReader(strings.NewReader("\uFEFFa,\"b\nc\",d\r\ne"), nil)
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err.Error())
+	}
+	for i, expect := range [][3]int{{1, 1, 3}, {1, 3, 5}, {2, 4, 11}} {
+		line, column := r.FieldPos(i)
+		offset := r.FieldOffset(i)
+		if line != expect[0] || column != expect[1] || offset != int64(expect[2]) {
+			t.Fatalf("field %d: expect %v, but %d:%d:%d", i, expect, line, column, offset)
+		}
+	}
+	if n := r.InputOffset(); n != 14 {
+		t.Fatalf("expect 14, but %d", n)
+	}
+	row, err := r.Read()
+	if err != nil || row.Cell[0].Text() != "e" {
+		t.Fatalf("expect e, but %v %v", row, err)
+	}
+	if line, column := r.FieldPos(0); line != 3 || column != 1 {
+		t.Fatalf("expect 3:1, but %d:%d", line, column)
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Fatalf("expect io.EOF, but %v", err)
+	}
+}
+
+func TestReaderErrQuote(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b\nc,\"d\ne,f\n"), nil)
+	rows, err := r.ReadAll()
+	var perr *ParseError
+	if !errors.As(err, &perr) || !errors.Is(err, ErrQuote) {
+		t.Fatalf("expect ErrQuote, but %v", err)
+	}
+	if perr.StartLine != 2 || perr.Line != 2 || perr.Column != 3 || perr.Offset != 6 {
+		t.Fatalf("unexpected position: %#v", perr)
+	}
+	if len(rows) != 2 || string(rows[1].Cell[1].Source()) != "\"d\ne,f\n" {
+		t.Fatalf("the rest of the data is not kept: %v", rows)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	mode := &Mode{Comma: ';', DefaultTerm: "\r\n"}
+	mode.SetBom(true)
+	var buffer strings.Builder
+	w := NewWriter(&buffer, mode)
+	w.WriteStrings([]string{"a", "b;c", "d\"e"})
+	r := NewReader(strings.NewReader("x;y"), &Mode{Comma: ';'})
+	row, _ := r.Read()
+	w.WriteRow(row)
+	w.WriteStrings([]string{"z"})
+	if err := w.Flush(); err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := "\uFEFFa;\"b;c\";\"d\"\"e\"\r\nx;y\r\nz\r\n"
+	if result := buffer.String(); result != expect {
+		t.Fatalf("expect %q, but %q", expect, result)
+	}
+}
@@ -0,0 +1,90 @@
+package uncsv
+
+import (
+	"bufio"
+	"io"
+)
+
+// Writer writes the rows like encoding/csv.Writer. The cells not changed
+// since they were read are written as the bytes read, and the new cells
+// are quoted only when they need it.
+type Writer struct {
+	w       *bufio.Writer
+	mode    *Mode
+	started bool
+	// unterminated is true when the last row is written without the line terminator
+	unterminated bool
+	err          error
+}
+
+// NewWriter returns the Writer which writes the rows to w in the encoding of mode.
+// The BOM is written before the first row when Mode.HasBom is true,
+// which can be changed with Mode.SetBom.
+// When mode is nil, the fields are separated by commas.
+func NewWriter(w io.Writer, mode *Mode) *Writer {
+	if mode == nil {
+		mode = &Mode{Comma: ','}
+	}
+	return &Writer{w: bufio.NewWriter(w), mode: mode}
+}
+
+func (w *Writer) start() {
+	if !w.started {
+		w.started = true
+		w.mode.writeBom(w.w)
+	}
+}
+
+// WriteRow writes the row. When the previous row does not have the line terminator
+// like the last row of the data, it is terminated before the row.
+func (w *Writer) WriteRow(row *Row) error {
+	if w.err != nil {
+		return w.err
+	}
+	data, err := row.bytes(w.mode)
+	if err != nil {
+		w.err = err
+		return err
+	}
+	w.start()
+	if w.unterminated {
+		for _, c := range []byte(w.mode.newline()) {
+			writeEndian(w.w, c, w.mode.endian)
+		}
+	}
+	_, w.err = w.w.Write(data)
+	w.unterminated = row.Term == ""
+	return w.err
+}
+
+// WriteStrings writes the row of the texts with the line terminator of the mode.
+func (w *Writer) WriteStrings(texts []string) error {
+	row := NewRowFromStringSlice(w.mode, texts)
+	return w.WriteRow(&row)
+}
+
+// WriteAll writes the rows and flushes them.
+func (w *Writer) WriteAll(rows []*Row) error {
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Flush writes the buffered data to the underlying writer.
+// The BOM is written even when no rows are written.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.start()
+	w.err = w.w.Flush()
+	return w.err
+}
+
+// Error returns the error which occurred in the previous WriteRow or Flush.
+func (w *Writer) Error() error {
+	return w.err
+}
@@ -0,0 +1,294 @@
+package csvi
+
+import (
+	"github.com/hymkor/csvi/uncsv"
+)
+
+// undoStep is the smallest unit of the operation log.
+// Both functions must be called only in the reverse order of recording.
+type undoStep struct {
+	undo func()
+	redo func()
+}
+
+// undoGroup is the set of steps done by one command.
+type undoGroup struct {
+	steps       []undoStep
+	lnumBefore  int
+	colBefore   int
+	lnumAfter   int
+	colAfter    int
+	dirtyBefore int
+	dirtyAfter  int
+}
+
+type history struct {
+	groups  []*undoGroup
+	pos     int
+	savedAt int
+	pending *undoGroup
+	changes int // counts commands, undo and redo to know the buffer changed
+}
+
+// rowImage is a copy of cells and the line terminator of a row.
+type rowImage struct {
+	cell []uncsv.Cell
+	term string
+}
+
+func takeRowImage(row *uncsv.Row) rowImage {
+	return rowImage{
+		cell: append([]uncsv.Cell{}, row.Cell...),
+		term: row.Term,
+	}
+}
+
+func (img rowImage) restoreTo(row *uncsv.Row, mode *uncsv.Mode) {
+	row.Load()
+	if len(img.cell) == len(row.Cell) {
+		// keep the current original values which may be updated by saving
+		for i := range img.cell {
+			row.Cell[i].SetSource(img.cell[i].Source(), mode)
+		}
+	} else {
+		row.Cell = append([]uncsv.Cell{}, img.cell...)
+	}
+	row.Term = img.term
+}
+
+func (app *Application) beginCommand() {
+	app.history.pending = &undoGroup{
+		lnumBefore:  app.cursorRow.Index(),
+		colBefore:   app.cursorCol,
+		dirtyBefore: app.dirty,
+	}
+}
+
+func (app *Application) endCommand() {
+	g := app.history.pending
+	app.history.pending = nil
+	if g == nil || len(g.steps) <= 0 {
+		return
+	}
+	g.lnumAfter = app.cursorRow.Index()
+	g.colAfter = app.cursorCol
+	g.dirtyAfter = app.dirty
+
+	h := &app.history
+	if h.savedAt > h.pos {
+		h.savedAt = -1
+	}
+	h.groups = append(h.groups[:h.pos], g)
+	h.pos = len(h.groups)
+	h.changes++
+}
+
+func (app *Application) record(undo, redo func()) {
+	app.invalidateFilterCount()
+	app.invalidateGhosts()
+	app.invalidateProblems()
+	if app.history.pending == nil {
+		app.beginCommand()
+	}
+	app.history.pending.steps = append(app.history.pending.steps,
+		undoStep{undo: undo, redo: redo})
+}
+
+// markHistorySaved is called when the current state is written to the file.
+func (app *Application) markHistorySaved() {
+	for _, g := range app.history.groups {
+		g.dirtyBefore |= 1
+		g.dirtyAfter |= 1
+	}
+	app.history.savedAt = app.history.pos
+}
+
+func (app *Application) resetHistory() {
+	// changes keeps counting for the swap file
+	app.history = history{changes: app.history.changes + 1}
+	if app.IsDirty() {
+		app.history.savedAt = -1
+	}
+}
+
+// seek returns the row at lnum, or the last row when lnum is beyond it, in O(log n).
+func (app *Application) seek(lnum int) *RowPtr {
+	if app.Len() <= 0 {
+		return nil
+	}
+	if lnum >= app.Len() {
+		return app.Back()
+	}
+	if lnum < 0 {
+		lnum = 0
+	}
+	return atPtr(app.csvLines, lnum)
+}
+
+// insertElementAt puts the element of a row, new or removed, at lnum.
+// The marks to the element removed get valid again.
+func (app *Application) insertElementAt(lnum int, e *rowElement) {
+	if lnum <= 0 {
+		app.csvLines.PushFrontElement(e)
+	} else {
+		app.csvLines.InsertElementAfter(e, app.seek(lnum-1).element)
+	}
+}
+
+func (app *Application) recordRowChange(row *uncsv.Row, before rowImage) {
+	after := takeRowImage(row)
+	app.record(
+		func() { before.restoreTo(row, app.Mode) },
+		func() { after.restoreTo(row, app.Mode) })
+}
+
+func (app *Application) recordInsertRow(p *RowPtr) {
+	lnum := p.Index()
+	e := p.element
+	app.record(
+		func() { app.seek(lnum).Remove() },
+		func() { app.insertElementAt(lnum, e) })
+}
+
+// removeRowSteps returns the functions to put the row back to lnum
+// and to remove it again keeping removedRows.
+func (app *Application) removeRowSteps(lnum int, e *rowElement) (restore, remove func()) {
+	restore = func() {
+		app.insertElementAt(lnum, e)
+		for i := len(app.removedRows) - 1; i >= 0; i-- {
+			if app.removedRows[i] == e {
+				app.removedRows = append(app.removedRows[:i], app.removedRows[i+1:]...)
+				break
+			}
+		}
+	}
+	remove = func() {
+		app.seek(lnum).Remove()
+		app.removedRows = append(app.removedRows, e)
+	}
+	return
+}
+
+func (app *Application) recordRemoveRow(lnum int, e *rowElement) {
+	app.record(app.removeRowSteps(lnum, e))
+}
+
+// recordRestoreRow records that the removed row is put back to lnum.
+func (app *Application) recordRestoreRow(lnum int, e *rowElement) {
+	for i := len(app.removedRows) - 1; i >= 0; i-- {
+		if app.removedRows[i] == e {
+			app.removedRows = append(app.removedRows[:i], app.removedRows[i+1:]...)
+			break
+		}
+	}
+	restore, remove := app.removeRowSteps(lnum, e)
+	app.record(remove, restore)
+}
+
+func (app *Application) moveAfterHistory(lnum, col, dirty int) {
+	if app.history.pos == app.history.savedAt {
+		app.dirty = 0
+	} else {
+		app.dirty = dirty
+	}
+	startLnum := app.startRow.Index()
+	app.cursorRow = app.seek(lnum)
+	app.cursorCol = col
+	if startLnum > app.cursorRow.Index() {
+		startLnum = app.cursorRow.Index()
+	}
+	app.startRow = app.seek(startLnum)
+	app.invalidateFilterCount()
+	app.invalidateGhosts()
+	app.invalidateProblems()
+	app.clearCache()
+}
+
+func (app *Application) undo() string {
+	h := &app.history
+	if h.pos <= 0 {
+		return "Already at oldest change"
+	}
+	h.pos--
+	h.changes++
+	g := h.groups[h.pos]
+	for i := len(g.steps) - 1; i >= 0; i-- {
+		g.steps[i].undo()
+	}
+	app.moveAfterHistory(g.lnumBefore, g.colBefore, g.dirtyBefore)
+	return ""
+}
+
+func (app *Application) redo() string {
+	h := &app.history
+	if h.pos >= len(h.groups) {
+		return "Already at newest change"
+	}
+	g := h.groups[h.pos]
+	h.pos++
+	h.changes++
+	for _, s := range g.steps {
+		s.redo()
+	}
+	app.moveAfterHistory(g.lnumAfter, g.colAfter, g.dirtyAfter)
+	return ""
+}
+
+// columnCell is a cell of a column. ok is false when the row did not have it.
+type columnCell struct {
+	cell uncsv.Cell
+	ok   bool
+}
+
+func (app *Application) recordColumnRemove(col int, removed []columnCell) {
+	app.record(
+		func() {
+			i := 0
+			for p := app.Front(); p != nil && i < len(removed); p = p.Next() {
+				if removed[i].ok {
+					p.InsertCell(col, removed[i].cell, app.Mode)
+				}
+				i++
+			}
+		},
+		func() {
+			i := 0
+			for p := app.Front(); p != nil && i < len(removed); p = p.Next() {
+				if removed[i].ok {
+					p.Delete(col)
+				}
+				i++
+			}
+		})
+}
+
+func (app *Application) recordColumnInsert(col int, inserted []uncsv.Cell) {
+	app.record(
+		func() {
+			i := 0
+			for p := app.Front(); p != nil && i < len(inserted); p = p.Next() {
+				p.Delete(col)
+				i++
+			}
+		},
+		func() {
+			i := 0
+			for p := app.Front(); p != nil && i < len(inserted); p = p.Next() {
+				p.InsertCell(col, inserted[i], app.Mode)
+				i++
+			}
+		})
+}
+
+func (app *Application) recordColumnOverwrite(col int, oldCells, newCells []uncsv.Cell) {
+	set := func(cells []uncsv.Cell) func() {
+		return func() {
+			i := 0
+			for p := app.Front(); p != nil && i < len(cells); p = p.Next() {
+				p.Cell[col].SetSource(cells[i].Source(), app.Mode)
+				i++
+			}
+		}
+	}
+	app.record(set(oldCells), set(newCells))
+}
@@ -0,0 +1,46 @@
+package csvi_test
+
+import (
+	"testing"
+)
+
+func TestUndoDeleteRow(t *testing.T) { // `dd` and `u`
+	src := "あ,い,う,え,お\nか,き,く,け,こ\nさ,し,す,せ,そ"
+	testCase(t, src, "j|d|d|u", src)
+	testCase(t, src, ">|d|d|u", src)
+	testCase(t, src, "j|d|d|u|\x12", "あ,い,う,え,お\nさ,し,す,せ,そ")
+}
+
+func TestUndoDeleteColumn(t *testing.T) { // `dc` and `u`
+	src := "あ,い,う,え,お\nか,き,く,け,こ"
+	testCase(t, src, "l|d|c|u", src)
+	testCase(t, src, "l|d|c|u|\x12", "あ,う,え,お\nか,く,け,こ")
+}
+
+func TestUndoPaste(t *testing.T) { // `p` and `u`
+	src := "あ,い,う,え,お\nか,き,く,け,こ"
+	testCase(t, src, "y|y|p|u", src)
+	testCase(t, src, "y|c|p|u", src)
+	testCase(t, src, "y|l|$|\x1Bp|u", src)
+	testCase(t, src, "y|c|p|u|\x12", "あ,あ,い,う,え,お\nか,か,き,く,け,こ")
+}
+
+func TestUndoSequence(t *testing.T) { // `o`, `i`, `r` and `u` several times
+	src := "あ,い,う,え,お\nか,き,く,け,こ"
+	op := ">|o|foo|i|bar|<|r|baz|u|u"
+	exp := "あ,い,う,え,お\nか,き,く,け,こ\nfoo"
+	testCase(t, src, op, exp)
+
+	op = ">|o|foo|i|bar|<|r|baz|u|u|u"
+	testCase(t, src, op, src)
+
+	op = ">|o|foo|i|bar|<|r|baz|u|u|u|\x12|\x12"
+	exp = "あ,い,う,え,お\nか,き,く,け,こ\nbar,foo"
+	testCase(t, src, op, exp)
+}
+
+func TestRestoreOriginal(t *testing.T) { // `U`
+	src := "あ,い,う,え,お\nか,き,く,け,こ"
+	testCase(t, src, "r|foo|r|bar|U", src)
+	testCase(t, src, "r|foo|r|bar|U|u", "bar,い,う,え,お\nか,き,く,け,こ")
+}
@@ -2,6 +2,7 @@ package csvi
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
@@ -19,16 +20,15 @@ import (
 )
 
 func (app *Application) dump(ctx context.Context, w io.Writer) error {
-	cursor := app.Front()
+	// the rows unloaded are copied from the file without reading them again
+	next := app.dumpRows()
 	return app.Config.Mode.DumpBy(
 		ctx,
 		func() *uncsv.Row {
-			if cursor == nil {
-				return nil
+			row := next()
+			if row != nil {
+				row.MarkAsSave()
 			}
-			row := cursor.Row
-			row.MarkAsSave()
-			cursor = cursor.Next()
 			return row
 		}, w)
 }
@@ -42,27 +42,30 @@ func (app *Application) dumpWithAnimationAndCancel(fd io.Writer) error {
 	return app.dump(ctx, fd)
 }
 
+// confirmOverwrite asks whether the existing file may be overwritten.
+func (app *Application) confirmOverwrite(info *safewrite.Info) bool {
+	if info.Status != safewrite.NONE {
+		return true
+	}
+	if info.ReadOnly() {
+		return app.yesNo("Overwrite READONLY file \"" + info.Name + "\" [y/n] ?")
+	}
+	return app.yesNo("Overwrite as \"" + info.Name + "\" [y/n] ?")
+}
+
 func (app *Application) cmdWrite(fname string) (string, error) {
 	if fname == "-" {
 		err := app.dumpWithAnimationAndCancel(os.Stdout)
 		return "Output to STDOUT", err
 	}
 
-	prompt := func(info *safewrite.Info) bool {
-		if info.Status != safewrite.NONE {
-			return true
-		}
-		if info.ReadOnly() {
-			return app.yesNo("Overwrite READONLY file \"" + info.Name + "\" [y/n] ?")
-		}
-		return app.yesNo("Overwrite as \"" + info.Name + "\" [y/n] ?")
-	}
-	fd, err := safewrite.Open(fname, prompt)
+	fd, err := safewrite.Open(fname, app.confirmOverwrite)
 	if err != nil {
 		return "", err
 	}
 
-	if err := app.dumpWithAnimationAndCancel(fd); err != nil {
+	h := sha256.New()
+	if err := app.dumpWithAnimationAndCancel(io.MultiWriter(fd, h)); err != nil {
 		return "", err
 	}
 
@@ -85,10 +88,52 @@ func (app *Application) cmdWrite(fname string) (string, error) {
 		return "", err
 	}
 	perm.Track(fd)
+	if stamp := newFileStamp(fname, nil); stamp != nil {
+		stamp.sum = h.Sum(nil)
+		app.stamp = stamp
+	}
 	return fmt.Sprintf("Saved as \"%s\"", fname), nil
 }
 
+// fetchAll reads all the rows which the background loader has not read yet.
+func (app *Application) fetchAll(ctx context.Context) {
+	app.fetchUntil(ctx, -1)
+}
+
+// fetchUntil reads rows from the background loader until the row of lnum is loaded.
+// A negative lnum means all rows.
+func (app *Application) fetchUntil(ctx context.Context, lnum int) {
+	for app.fetchFunc != nil && (lnum < 0 || app.Len() <= lnum) {
+		if ctx.Err() != nil {
+			return
+		}
+		row, err := app.fetchFunc()
+		if errors.Is(err, errFollowIdle) {
+			// the rows appended later are read by the main loop
+			return
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			app.fetchFunc = nil
+			app.tryFetchFunc = nil
+			return
+		}
+		if !row.IsZero() {
+			app.load(row)
+		}
+		if errors.Is(err, io.EOF) {
+			app.fetchFunc = nil
+			app.tryFetchFunc = nil
+			return
+		}
+	}
+}
+
 func (app *Application) cmdSave() (string, error) {
+	return app.cmdSaveAs("")
+}
+
+// cmdSaveAs writes all rows to fname. When fname is empty, it is asked.
+func (app *Application) cmdSaveAs(fname string) (string, error) {
 	var wg sync.WaitGroup
 
 	ctx, cancel := app.ctrlC.NotifyContext(context.Background())
@@ -97,30 +142,17 @@ func (app *Application) cmdSave() (string, error) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for {
-				if ctx.Err() != nil {
-					return
-				}
-				row, err := app.fetchFunc()
-				if err != nil && !errors.Is(err, io.EOF) {
-					app.fetchFunc = nil
-					app.tryFetchFunc = nil
-					return
-				}
-				if row != nil {
-					app.push(row)
-				}
-				if errors.Is(err, io.EOF) {
-					app.fetchFunc = nil
-					app.tryFetchFunc = nil
-					return
-				}
-			}
+			app.fetchAll(ctx)
 		}()
 	}
-	fname, err := app.GetFilename(app, "write to>", app.getSavePath())
-	if err != nil {
-		return "", err
+	if fname == "" {
+		var err error
+		fname, err = app.GetFilename(app, "write to>", app.getSavePath())
+		if err != nil {
+			cancel()
+			wg.Wait()
+			return "", err
+		}
 	}
 	io.WriteString(app.out, ansi.YELLOW+"\rReading all data... "+ansi.ERASE_SCRN_AFTER)
 	end := animation.Dots.Progress(app.out)
@@ -131,9 +163,15 @@ func (app *Application) cmdSave() (string, error) {
 	if ctxErr != nil {
 		return "", errors.New("Save interrupted")
 	}
-	message, err := app.cmdWrite(fname)
+	fname, message, err := app.confirmExternalChange(fname)
+	if fname == "" || err != nil {
+		return message, err
+	}
+	message, err = app.cmdWrite(fname)
 	if err == nil {
 		app.resetDirty()
+		app.markHistorySaved()
+		app.rebase()
 	}
 	app.lastSavePath = fname
 	return message, err